@@ -0,0 +1,32 @@
+package builder
+
+import (
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+type BudgieCmdBuilder struct {
+	config         *config.Config
+	pictureOptions string
+}
+
+func (b *BudgieCmdBuilder) Init(config *config.Config) {
+	b.config = config
+	b.pictureOptions = resolvePictureOptions(config)
+}
+
+// Build returns command setting wallpaper. Budgie reads
+// background from GNOME schema but, unlike GNOME builder,
+// picture options are set too.
+func (b *BudgieCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return gsettingsBackground(
+		"org.gnome.desktop.background",
+		"picture-uri",
+		fmt.Sprintf("file://%s", imgPath),
+		b.pictureOptions,
+	)
+}
@@ -0,0 +1,29 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBudgieCmdBuilder_Build(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.LocalStoragePath = "/home/user/.blider/images"
+	cfg.Desktop.PictureOptions = "scaled"
+
+	b := &BudgieCmdBuilder{}
+	b.Init(cfg)
+
+	cmd := b.Build(&repository.Wallpaper{Filename: "fjord.png"})
+	assert.Equal(t, []string{
+		"sh",
+		"-c",
+		gsettingsScript,
+		"sh",
+		"org.gnome.desktop.background",
+		"picture-uri",
+		"file:///home/user/.blider/images/fjord.png",
+		"scaled",
+	}, cmd.Args)
+}
@@ -4,9 +4,21 @@ import (
 	"github.com/ildarkarymoff/blider/config"
 	"github.com/ildarkarymoff/blider/repository"
 	"os/exec"
+	"path/filepath"
 )
 
 type ICmdBuilder interface {
 	Init(config *config.Config)
+	// Build returns command setting wallpaper or nil
+	// if command can't be built for wallpaper.
 	Build(wallpaper *repository.Wallpaper) *exec.Cmd
 }
+
+// imagePath returns absolute path to wallpaper image file.
+func imagePath(config *config.Config, wallpaper *repository.Wallpaper) string {
+	if len(wallpaper.LocalPath) > 0 {
+		return wallpaper.LocalPath
+	}
+
+	return filepath.Join(config.LocalStoragePath, wallpaper.Filename)
+}
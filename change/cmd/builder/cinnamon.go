@@ -0,0 +1,29 @@
+package builder
+
+import (
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+type CinnamonCmdBuilder struct {
+	config         *config.Config
+	pictureOptions string
+}
+
+func (b *CinnamonCmdBuilder) Init(config *config.Config) {
+	b.config = config
+	b.pictureOptions = resolvePictureOptions(config)
+}
+
+func (b *CinnamonCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return gsettingsBackground(
+		"org.cinnamon.desktop.background",
+		"picture-uri",
+		fmt.Sprintf("file://%s", imgPath),
+		b.pictureOptions,
+	)
+}
@@ -0,0 +1,29 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCinnamonCmdBuilder_Build(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.LocalStoragePath = "/home/user/.blider/images"
+	cfg.Desktop.PictureOptions = "spanned"
+
+	b := &CinnamonCmdBuilder{}
+	b.Init(cfg)
+
+	cmd := b.Build(&repository.Wallpaper{Filename: "my dunes.jpg"})
+	assert.Equal(t, []string{
+		"sh",
+		"-c",
+		gsettingsScript,
+		"sh",
+		"org.cinnamon.desktop.background",
+		"picture-uri",
+		"file:///home/user/.blider/images/my dunes.jpg",
+		"spanned",
+	}, cmd.Args)
+}
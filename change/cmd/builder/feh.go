@@ -0,0 +1,23 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+type FehCmdBuilder struct {
+	config *config.Config
+}
+
+func (b *FehCmdBuilder) Init(config *config.Config) {
+	b.config = config
+}
+
+// Build returns command setting wallpaper scaled to fill screen.
+// feh also saves it to ~/.fehbg, so it can be restored on login.
+func (b *FehCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return exec.Command("feh", "--bg-fill", imgPath)
+}
@@ -0,0 +1,19 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFehCmdBuilder_Build(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.LocalStoragePath = "/home/user/.blider/images"
+
+	b := &FehCmdBuilder{}
+	b.Init(cfg)
+
+	cmd := b.Build(&repository.Wallpaper{Filename: "fjord.png"})
+	assert.Equal(t, []string{"feh", "--bg-fill", "/home/user/.blider/images/fjord.png"}, cmd.Args)
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/ildarkarymoff/blider/config"
 	"github.com/ildarkarymoff/blider/repository"
 	"os/exec"
-	"path/filepath"
 )
 
 type GnomeCmdBuilder struct {
@@ -17,7 +16,7 @@ func (b *GnomeCmdBuilder) Init(config *config.Config) {
 }
 
 func (b *GnomeCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
-	imgPath := filepath.Join(b.config.LocalStoragePath, wallpaper.Filename)
+	imgPath := imagePath(b.config, wallpaper)
 
 	return exec.Command(
 		"gsettings",
@@ -0,0 +1,59 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"log"
+	"os/exec"
+)
+
+const (
+	// gsettingsScript sets picture key and picture-options key of
+	// background schema. Schema, picture key, picture and options
+	// are passed as positional arguments, so they need no quoting.
+	gsettingsScript = `gsettings set "$1" "$2" "$3" && gsettings set "$1" picture-options "$4"`
+
+	defaultPictureOptions = "zoom"
+)
+
+// pictureOptions is set of values of picture-options key
+// shared by GNOME-derived desktops.
+var pictureOptions = map[string]bool{
+	"none":      true,
+	"wallpaper": true,
+	"centered":  true,
+	"scaled":    true,
+	"stretched": true,
+	"zoom":      true,
+	"spanned":   true,
+}
+
+// resolvePictureOptions returns configured picture-options
+// value or default one if configured value is unknown.
+func resolvePictureOptions(config *config.Config) string {
+	options := config.Desktop.PictureOptions
+	if !pictureOptions[options] {
+		log.Printf(
+			"Unknown picture options '%s'. Switching to %s...",
+			options,
+			defaultPictureOptions,
+		)
+		return defaultPictureOptions
+	}
+
+	return options
+}
+
+// gsettingsBackground returns command setting picture and
+// picture options of background schema.
+func gsettingsBackground(schema, pictureKey, picture, options string) *exec.Cmd {
+	return exec.Command(
+		"sh",
+		"-c",
+		gsettingsScript,
+		"sh",
+		schema,
+		pictureKey,
+		picture,
+		options,
+	)
+}
@@ -0,0 +1,21 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResolvePictureOptions(t *testing.T) {
+	cfg := config.NewDefault()
+
+	cfg.Desktop.PictureOptions = "wallpaper"
+	assert.Equal(t, "wallpaper", resolvePictureOptions(cfg))
+
+	// Unknown and missing options fall back to zoom.
+	cfg.Desktop.PictureOptions = "fill"
+	assert.Equal(t, "zoom", resolvePictureOptions(cfg))
+
+	cfg.Desktop.PictureOptions = ""
+	assert.Equal(t, "zoom", resolvePictureOptions(cfg))
+}
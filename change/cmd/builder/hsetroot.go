@@ -0,0 +1,22 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+type HsetrootCmdBuilder struct {
+	config *config.Config
+}
+
+func (b *HsetrootCmdBuilder) Init(config *config.Config) {
+	b.config = config
+}
+
+// Build returns command setting wallpaper scaled to fill screen.
+func (b *HsetrootCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return exec.Command("hsetroot", "-cover", imgPath)
+}
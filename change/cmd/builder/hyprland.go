@@ -0,0 +1,28 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+// hyprpaperScript preloads image passed as positional argument,
+// sets it on all monitors and unloads images no longer shown,
+// so hyprpaper doesn't keep every wallpaper in memory.
+const hyprpaperScript = `hyprctl hyprpaper preload "$1" &&
+hyprctl hyprpaper wallpaper ",$1" &&
+hyprctl hyprpaper unload unused`
+
+type HyprlandCmdBuilder struct {
+	config *config.Config
+}
+
+func (b *HyprlandCmdBuilder) Init(config *config.Config) {
+	b.config = config
+}
+
+func (b *HyprlandCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return exec.Command("sh", "-c", hyprpaperScript, "sh", imgPath)
+}
@@ -0,0 +1,22 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHyprlandCmdBuilder_Build(t *testing.T) {
+	b := &HyprlandCmdBuilder{}
+	b.Init(config.NewDefault())
+
+	cmd := b.Build(&repository.Wallpaper{LocalPath: "/home/user/Pictures/fjord.png"})
+	assert.Equal(t, []string{
+		"sh",
+		"-c",
+		hyprpaperScript,
+		"sh",
+		"/home/user/Pictures/fjord.png",
+	}, cmd.Args)
+}
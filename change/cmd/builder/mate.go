@@ -0,0 +1,30 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+type MateCmdBuilder struct {
+	config         *config.Config
+	pictureOptions string
+}
+
+func (b *MateCmdBuilder) Init(config *config.Config) {
+	b.config = config
+	b.pictureOptions = resolvePictureOptions(config)
+}
+
+// Build returns command setting wallpaper. Unlike other
+// GNOME-derived desktops, MATE takes plain path, not URI.
+func (b *MateCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return gsettingsBackground(
+		"org.mate.background",
+		"picture-filename",
+		imgPath,
+		b.pictureOptions,
+	)
+}
@@ -0,0 +1,29 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMateCmdBuilder_Build(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Desktop.PictureOptions = "centered"
+
+	b := &MateCmdBuilder{}
+	b.Init(cfg)
+
+	// MATE takes plain path instead of URI.
+	cmd := b.Build(&repository.Wallpaper{LocalPath: "/home/user/Pictures/fjord.png"})
+	assert.Equal(t, []string{
+		"sh",
+		"-c",
+		gsettingsScript,
+		"sh",
+		"org.mate.background",
+		"picture-filename",
+		"/home/user/Pictures/fjord.png",
+		"centered",
+	}, cmd.Args)
+}
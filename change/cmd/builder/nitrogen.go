@@ -0,0 +1,24 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+type NitrogenCmdBuilder struct {
+	config *config.Config
+}
+
+func (b *NitrogenCmdBuilder) Init(config *config.Config) {
+	b.config = config
+}
+
+// Build returns command setting wallpaper scaled to fill screen
+// and saving it to nitrogen configuration, so it can be restored
+// on login with nitrogen --restore.
+func (b *NitrogenCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return exec.Command("nitrogen", "--set-zoom-fill", "--save", imgPath)
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/ildarkarymoff/blider/config"
 	"github.com/ildarkarymoff/blider/repository"
 	"os/exec"
-	"path/filepath"
 )
 
 const (
@@ -30,7 +29,7 @@ func (b *PlasmaCmdBuilder) Init(config *config.Config) {
 }
 
 func (b *PlasmaCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
-	imgPath := filepath.Join(b.config.LocalStoragePath, wallpaper.Filename)
+	imgPath := imagePath(b.config, wallpaper)
 	script := fmt.Sprintf(scriptFmt, imgPath)
 	return exec.Command(
 		"qdbus",
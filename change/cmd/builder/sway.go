@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+const swayDefaultMode = "fill"
+
+// swayModes is set of background modes of sway outputs.
+var swayModes = map[string]bool{
+	"stretch": true,
+	"fill":    true,
+	"fit":     true,
+	"center":  true,
+	"tile":    true,
+}
+
+type SwayCmdBuilder struct {
+	config *config.Config
+	mode   string
+}
+
+func (b *SwayCmdBuilder) Init(config *config.Config) {
+	b.config = config
+
+	b.mode = config.Desktop.Sway.Mode
+	if !swayModes[b.mode] {
+		log.Printf("Unknown sway mode '%s'. Switching to %s...", b.mode, swayDefaultMode)
+		b.mode = swayDefaultMode
+	}
+}
+
+// Build returns command setting wallpaper of all outputs.
+// swaymsg joins its arguments into one command, so path
+// is quoted to survive spaces.
+func (b *SwayCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return exec.Command(
+		"swaymsg",
+		"output",
+		"*",
+		"bg",
+		swayQuote(imgPath),
+		b.mode,
+	)
+}
+
+// swayQuoter escapes characters having special meaning
+// inside quoted string of sway command.
+var swayQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// swayQuote wraps s in double quotes the way sway commands
+// expect. Unlike Go quoting, other characters are kept as is.
+func swayQuote(s string) string {
+	return `"` + swayQuoter.Replace(s) + `"`
+}
@@ -0,0 +1,31 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSwayCmdBuilder_Build(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.LocalStoragePath = `/home/user/Картинки/"best" \ walls`
+	cfg.Desktop.Sway.Mode = "fit"
+
+	b := &SwayCmdBuilder{}
+	b.Init(cfg)
+
+	cmd := b.Build(&repository.Wallpaper{Filename: "été.jpg"})
+	assert.Equal(t, []string{
+		"swaymsg",
+		"output",
+		"*",
+		"bg",
+		`"/home/user/Картинки/\"best\" \\ walls/été.jpg"`,
+		"fit",
+	}, cmd.Args)
+
+	cfg.Desktop.Sway.Mode = "zoom"
+	b.Init(cfg)
+	assert.Equal(t, "fill", b.Build(&repository.Wallpaper{Filename: "a.jpg"}).Args[5])
+}
@@ -0,0 +1,39 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+	"strconv"
+)
+
+type SwwwCmdBuilder struct {
+	config *config.Config
+}
+
+func (b *SwwwCmdBuilder) Init(config *config.Config) {
+	b.config = config
+}
+
+// Build returns command asking swww daemon to show wallpaper.
+// Transition options not set in configuration are left to swww.
+func (b *SwwwCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+	options := b.config.Desktop.Swww
+
+	args := []string{"img", imgPath}
+
+	if len(options.TransitionType) > 0 {
+		args = append(args, "--transition-type", options.TransitionType)
+	}
+
+	if options.TransitionDuration > 0 {
+		args = append(
+			args,
+			"--transition-duration",
+			strconv.FormatFloat(options.TransitionDuration, 'f', -1, 64),
+		)
+	}
+
+	return exec.Command("swww", args...)
+}
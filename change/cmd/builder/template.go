@@ -0,0 +1,92 @@
+package builder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// TemplateCmdBuilder runs user-defined command. Each argument
+// is text/template rendered over wallpaper fields and absolute
+// path to image, e.g. "{{.Path}}" or "{{.Title}}".
+type TemplateCmdBuilder struct {
+	config *config.Config
+	args   []*template.Template
+}
+
+// templateData is data command template is rendered with.
+type templateData struct {
+	*repository.Wallpaper
+	// Path is absolute path to image file.
+	Path string
+}
+
+// NewTemplateCmdBuilder parses command template. Returns error if
+// template or command name is empty or some of arguments are
+// malformed or refer to unknown fields.
+func NewTemplateCmdBuilder(command []string) (*TemplateCmdBuilder, error) {
+	if len(command) == 0 {
+		return nil, errors.New("command template is empty")
+	}
+
+	if len(strings.TrimSpace(command[0])) == 0 {
+		return nil, errors.New("command name is empty")
+	}
+
+	b := &TemplateCmdBuilder{}
+
+	// Arguments are rendered with sample wallpaper once,
+	// so unknown fields are reported at start.
+	sample := &templateData{Wallpaper: &repository.Wallpaper{}}
+
+	for i, arg := range command {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+			return nil, err
+		}
+
+		b.args = append(b.args, tmpl)
+	}
+
+	return b, nil
+}
+
+func (b *TemplateCmdBuilder) Init(config *config.Config) {
+	b.config = config
+}
+
+// Build renders command template. Returns nil if some of
+// arguments can't be rendered or command name is empty.
+func (b *TemplateCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	data := &templateData{
+		Wallpaper: wallpaper,
+		Path:      imagePath(b.config, wallpaper),
+	}
+
+	args := make([]string, len(b.args))
+	for i, tmpl := range b.args {
+		var arg bytes.Buffer
+		if err := tmpl.Execute(&arg, data); err != nil {
+			log.Printf("[Render argument #%d of command template] %v", i+1, err)
+			return nil
+		}
+		args[i] = arg.String()
+	}
+
+	if len(strings.TrimSpace(args[0])) == 0 {
+		log.Println("[Render command template] command name is empty")
+		return nil
+	}
+
+	return exec.Command(args[0], args[1:]...)
+}
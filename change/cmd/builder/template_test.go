@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTemplateCmdBuilder_Build(t *testing.T) {
+	b, err := NewTemplateCmdBuilder([]string{
+		"mytool",
+		"--set",
+		"{{.Path}}",
+		"--title",
+		"{{.Title}} by {{.Author}}",
+	})
+	assert.NoError(t, err)
+
+	b.Init(&config.Config{LocalStoragePath: "/home/user/.blider/images"})
+
+	cmd := b.Build(&repository.Wallpaper{
+		Filename: "sunset.jpg",
+		Title:    "Sunset",
+		Author:   "Jane Doe",
+	})
+	assert.Equal(t, []string{
+		"mytool",
+		"--set",
+		"/home/user/.blider/images/sunset.jpg",
+		"--title",
+		"Sunset by Jane Doe",
+	}, cmd.Args)
+
+	cmd = b.Build(&repository.Wallpaper{
+		Filename:  "mine.png",
+		LocalPath: "/home/user/Pictures/mine.png",
+	})
+	assert.Equal(t, "/home/user/Pictures/mine.png", cmd.Args[2])
+}
+
+func TestNewTemplateCmdBuilder(t *testing.T) {
+	_, err := NewTemplateCmdBuilder(nil)
+	assert.Error(t, err)
+
+	_, err = NewTemplateCmdBuilder([]string{"mytool", "{{.Path"})
+	assert.Error(t, err)
+
+	_, err = NewTemplateCmdBuilder([]string{"mytool", "{{.Unknown}}"})
+	assert.Error(t, err)
+
+	_, err = NewTemplateCmdBuilder([]string{" ", "{{.Path}}"})
+	assert.Error(t, err)
+}
+
+func TestTemplateCmdBuilder_BuildFails(t *testing.T) {
+	// Command name rendered from empty field is empty.
+	b, err := NewTemplateCmdBuilder([]string{"{{.Provider}}", "{{.Path}}"})
+	assert.NoError(t, err)
+	b.Init(config.NewDefault())
+
+	assert.Nil(t, b.Build(&repository.Wallpaper{Filename: "a.png"}))
+	assert.NotNil(t, b.Build(&repository.Wallpaper{Filename: "a.png", Provider: "true"}))
+
+	// Rendering fails on index out of range that sample
+	// wallpaper checked at start doesn't reach.
+	b, err = NewTemplateCmdBuilder([]string{"mytool", `{{if .Title}}{{index .ImgBuffer 0}}{{end}}`})
+	assert.NoError(t, err)
+	b.Init(config.NewDefault())
+
+	assert.Nil(t, b.Build(&repository.Wallpaper{Title: "Dunes"}))
+	assert.Equal(t, []string{"mytool", "137"}, b.Build(&repository.Wallpaper{Title: "Dunes", ImgBuffer: []byte{137}}).Args)
+}
@@ -0,0 +1,71 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"os/exec"
+	"strconv"
+)
+
+const (
+	// xfceScript sets image of every monitor and workspace xfdesktop
+	// knows about. Image path and style are passed as positional
+	// arguments, so path needs no quoting.
+	xfceScript = `found=0
+for property in $(xfconf-query -c xfce4-desktop -l | grep '^/backdrop/screen[0-9]*/monitor[^/]*/workspace[0-9]*/last-image$'); do
+	found=1
+	xfconf-query -c xfce4-desktop -p "$property" -n -t string -s "$1" || exit 1
+	xfconf-query -c xfce4-desktop -p "${property%/last-image}/image-style" -n -t int -s "$2" || exit 1
+done
+if [ "$found" -eq 0 ]; then
+	echo "no backdrop properties found in xfce4-desktop channel" >&2
+	exit 1
+fi`
+
+	xfceDefaultImageStyle = "zoomed"
+)
+
+// xfceImageStyles maps image styles to values of xfdesktop
+// image-style property.
+var xfceImageStyles = map[string]int{
+	"centered":  1,
+	"tiled":     2,
+	"stretched": 3,
+	"scaled":    4,
+	"zoomed":    5,
+	"spanning":  6,
+}
+
+type XfceCmdBuilder struct {
+	config     *config.Config
+	imageStyle int
+}
+
+func (b *XfceCmdBuilder) Init(config *config.Config) {
+	b.config = config
+
+	style, ok := xfceImageStyles[config.Desktop.Xfce.ImageStyle]
+	if !ok {
+		log.Printf(
+			"Unknown XFCE image style '%s'. Switching to %s...",
+			config.Desktop.Xfce.ImageStyle,
+			xfceDefaultImageStyle,
+		)
+		style = xfceImageStyles[xfceDefaultImageStyle]
+	}
+	b.imageStyle = style
+}
+
+func (b *XfceCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return exec.Command(
+		"sh",
+		"-c",
+		xfceScript,
+		"sh",
+		imgPath,
+		strconv.Itoa(b.imageStyle),
+	)
+}
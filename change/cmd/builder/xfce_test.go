@@ -0,0 +1,32 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestXfceCmdBuilder_Build(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.LocalStoragePath = "/home/user/.blider/images"
+	cfg.Desktop.Xfce.ImageStyle = "spanning"
+
+	b := &XfceCmdBuilder{}
+	b.Init(cfg)
+
+	cmd := b.Build(&repository.Wallpaper{Filename: "my dunes.jpg"})
+	assert.Equal(t, []string{
+		"sh",
+		"-c",
+		xfceScript,
+		"sh",
+		"/home/user/.blider/images/my dunes.jpg",
+		"6",
+	}, cmd.Args)
+
+	// Unknown style falls back to zoomed.
+	cfg.Desktop.Xfce.ImageStyle = "fill"
+	b.Init(cfg)
+	assert.Equal(t, "5", b.Build(&repository.Wallpaper{Filename: "a.jpg"}).Args[5])
+}
@@ -0,0 +1,22 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"os/exec"
+)
+
+type XwallpaperCmdBuilder struct {
+	config *config.Config
+}
+
+func (b *XwallpaperCmdBuilder) Init(config *config.Config) {
+	b.config = config
+}
+
+// Build returns command setting wallpaper scaled to fill screen.
+func (b *XwallpaperCmdBuilder) Build(wallpaper *repository.Wallpaper) *exec.Cmd {
+	imgPath := imagePath(b.config, wallpaper)
+
+	return exec.Command("xwallpaper", "--zoom", imgPath)
+}
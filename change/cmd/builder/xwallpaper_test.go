@@ -0,0 +1,19 @@
+package builder
+
+import (
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestXwallpaperCmdBuilder_Build(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.LocalStoragePath = "/home/user/.blider/images"
+
+	b := &XwallpaperCmdBuilder{}
+	b.Init(cfg)
+
+	cmd := b.Build(&repository.Wallpaper{Filename: "my dunes.jpg"})
+	assert.Equal(t, []string{"xwallpaper", "--zoom", "/home/user/.blider/images/my dunes.jpg"}, cmd.Args)
+}
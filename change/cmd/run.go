@@ -2,13 +2,20 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"log"
 	"os/exec"
 	"strings"
 	"syscall"
 )
 
+// Run runs command built by builder and logs its output.
+// Returns error if builder has failed to build command.
 func Run(cmd *exec.Cmd) error {
+	if cmd == nil {
+		return errors.New("command setting wallpaper has not been built")
+	}
+
 	var output bytes.Buffer
 	cmd.Stdout = &output
 
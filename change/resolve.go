@@ -7,14 +7,34 @@ import (
 	"github.com/ildarkarymoff/blider/config"
 	"log"
 	"os"
+	"os/exec"
 	"runtime"
+	"strings"
 )
 
 const (
 	osLinux = "linux"
 
-	deKde   = "KDE"
-	deGnome = "GNOME"
+	deKde      = "KDE"
+	deGnome    = "GNOME"
+	deXfce     = "XFCE"
+	deCinnamon = "X-Cinnamon"
+	deMate     = "MATE"
+	deBudgie   = "Budgie"
+	deSway     = "sway"
+	deHyprland = "Hyprland"
+	// deWayland is any other Wayland compositor.
+	deWayland = "Wayland"
+
+	// Wallpaper setters of X11 window managers are named
+	// after their binaries.
+	wmFeh        = "feh"
+	wmNitrogen   = "nitrogen"
+	wmXwallpaper = "xwallpaper"
+	wmHsetroot   = "hsetroot"
+
+	// deTemplate is user-defined command.
+	deTemplate = "template"
 )
 
 var (
@@ -25,6 +45,23 @@ var (
 	supportedDe = envList{
 		deKde,
 		deGnome,
+		deXfce,
+		deCinnamon,
+		deMate,
+		deBudgie,
+		deSway,
+		deHyprland,
+		deWayland,
+		deTemplate,
+	}
+
+	// wmSetters is list of wallpaper setters in order
+	// they are looked for in PATH.
+	wmSetters = envList{
+		wmFeh,
+		wmNitrogen,
+		wmXwallpaper,
+		wmHsetroot,
 	}
 )
 
@@ -51,19 +88,55 @@ func ResolveBuilder(config *config.Config) (*builder.ICmdBuilder, error) {
 	}
 
 	if goos == "linux" {
-		return resolveDesktopEnvironment(config), nil
+		return resolveDesktopEnvironment(config)
 	}
 
 	return nil, errors.New("environment is not supported")
 }
 
-func resolveDesktopEnvironment(config *config.Config) *builder.ICmdBuilder {
+// resolveDesktopEnvironment returns builder of desktop environment
+// forced in configuration or detected. Returns error if forced
+// desktop environment is unknown or command template is malformed.
+func resolveDesktopEnvironment(config *config.Config) (*builder.ICmdBuilder, error) {
 	builders := map[string]builder.ICmdBuilder{
-		deKde:   &builder.PlasmaCmdBuilder{},
-		deGnome: &builder.GnomeCmdBuilder{},
+		deKde:      &builder.PlasmaCmdBuilder{},
+		deGnome:    &builder.GnomeCmdBuilder{},
+		deXfce:     &builder.XfceCmdBuilder{},
+		deCinnamon: &builder.CinnamonCmdBuilder{},
+		deMate:     &builder.MateCmdBuilder{},
+		deBudgie:   &builder.BudgieCmdBuilder{},
+		deSway:     &builder.SwayCmdBuilder{},
+		deHyprland: &builder.HyprlandCmdBuilder{},
+		deWayland:  &builder.SwwwCmdBuilder{},
+
+		wmFeh:        &builder.FehCmdBuilder{},
+		wmNitrogen:   &builder.NitrogenCmdBuilder{},
+		wmXwallpaper: &builder.XwallpaperCmdBuilder{},
+		wmHsetroot:   &builder.HsetrootCmdBuilder{},
+	}
+
+	// Command template makes sense only if it's set, so
+	// template builder is created on demand.
+	if config.Desktop.Environment == deTemplate {
+		templateBuilder, err := builder.NewTemplateCmdBuilder(config.Desktop.Command)
+		if err != nil {
+			return nil, fmt.Errorf("[Command template] %v", err)
+		}
+		builders[deTemplate] = templateBuilder
+	}
+
+	if de := config.Desktop.Environment; len(de) > 0 {
+		cmdBuilder, ok := builders[de]
+		if !ok {
+			return nil, fmt.Errorf("desktop environment '%s' is not supported", de)
+		}
+
+		log.Printf("Desktop environment set in configuration: %s", de)
+
+		return &cmdBuilder, nil
 	}
 
-	de := os.Getenv("XDG_CURRENT_DESKTOP")
+	de := detectDesktopEnvironment(builders)
 	cmdBuilder, ok := builders[de]
 
 	if !ok {
@@ -75,5 +148,42 @@ func resolveDesktopEnvironment(config *config.Config) *builder.ICmdBuilder {
 
 	log.Printf("Detected desktop environment: %s", de)
 
-	return &cmdBuilder
+	return &cmdBuilder, nil
+}
+
+// detectDesktopEnvironment returns name of desktop environment
+// running or empty string if it has no builder. Compositors are
+// recognized by their sockets first, because XDG_CURRENT_DESKTOP
+// is often not set in sessions started without display manager.
+// Other Wayland compositors get generic swww builder. X11 window
+// managers without desktop environment get builder of the first
+// wallpaper setter found in PATH.
+func detectDesktopEnvironment(builders map[string]builder.ICmdBuilder) string {
+	if len(os.Getenv("SWAYSOCK")) > 0 {
+		return deSway
+	}
+
+	if len(os.Getenv("HYPRLAND_INSTANCE_SIGNATURE")) > 0 {
+		return deHyprland
+	}
+
+	// XDG_CURRENT_DESKTOP may list several names separated
+	// by colons (e.g. "Budgie:GNOME"), the most specific first.
+	for _, name := range strings.Split(os.Getenv("XDG_CURRENT_DESKTOP"), ":") {
+		if _, ok := builders[name]; ok {
+			return name
+		}
+	}
+
+	if len(os.Getenv("WAYLAND_DISPLAY")) > 0 {
+		return deWayland
+	}
+
+	for _, name := range wmSetters {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+
+	return ""
 }
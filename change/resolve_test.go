@@ -0,0 +1,159 @@
+package change
+
+import (
+	"github.com/ildarkarymoff/blider/change/cmd/builder"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// detectionVars is list of environment variables
+// desktop environment is detected by.
+var detectionVars = []string{
+	"SWAYSOCK",
+	"HYPRLAND_INSTANCE_SIGNATURE",
+	"XDG_CURRENT_DESKTOP",
+	"WAYLAND_DISPLAY",
+	"PATH",
+}
+
+// setEnv replaces detection variables with vars (unset if missing)
+// and returns function restoring their original values.
+func setEnv(t *testing.T, vars map[string]string) func() {
+	original := make(map[string]*string)
+	for _, name := range detectionVars {
+		if value, ok := os.LookupEnv(name); ok {
+			original[name] = &value
+		} else {
+			original[name] = nil
+		}
+
+		if value, ok := vars[name]; ok {
+			assert.NoError(t, os.Setenv(name, value))
+		} else {
+			assert.NoError(t, os.Unsetenv(name))
+		}
+	}
+
+	return func() {
+		for name, value := range original {
+			if value != nil {
+				_ = os.Setenv(name, *value)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+func TestResolveDesktopEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blider_resolve_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Stub PATH has some of wallpaper setters.
+	stubPath := filepath.Join(dir, "bin")
+	assert.NoError(t, os.MkdirAll(stubPath, 0755))
+	for _, name := range []string{"xwallpaper", "hsetroot"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(stubPath, name), []byte("#!/bin/sh\n"), 0755))
+	}
+	emptyPath := filepath.Join(dir, "empty")
+	assert.NoError(t, os.MkdirAll(emptyPath, 0755))
+
+	cases := []struct {
+		name string
+		vars map[string]string
+		want builder.ICmdBuilder
+	}{
+		{
+			"sway socket wins",
+			map[string]string{"SWAYSOCK": "/run/sway.sock", "XDG_CURRENT_DESKTOP": "GNOME", "WAYLAND_DISPLAY": "wayland-1"},
+			&builder.SwayCmdBuilder{},
+		},
+		{
+			"hyprland signature",
+			map[string]string{"HYPRLAND_INSTANCE_SIGNATURE": "abc", "WAYLAND_DISPLAY": "wayland-1"},
+			&builder.HyprlandCmdBuilder{},
+		},
+		{
+			"desktop before wayland",
+			map[string]string{"XDG_CURRENT_DESKTOP": "KDE", "WAYLAND_DISPLAY": "wayland-0"},
+			&builder.PlasmaCmdBuilder{},
+		},
+		{
+			"the first supported desktop of list",
+			map[string]string{"XDG_CURRENT_DESKTOP": "Budgie:GNOME"},
+			&builder.BudgieCmdBuilder{},
+		},
+		{
+			"unsupported desktop skipped in list",
+			map[string]string{"XDG_CURRENT_DESKTOP": "ubuntu:GNOME"},
+			&builder.GnomeCmdBuilder{},
+		},
+		{
+			"xfce",
+			map[string]string{"XDG_CURRENT_DESKTOP": "XFCE"},
+			&builder.XfceCmdBuilder{},
+		},
+		{
+			"cinnamon",
+			map[string]string{"XDG_CURRENT_DESKTOP": "X-Cinnamon"},
+			&builder.CinnamonCmdBuilder{},
+		},
+		{
+			"other wayland compositor",
+			map[string]string{"XDG_CURRENT_DESKTOP": "river", "WAYLAND_DISPLAY": "wayland-0", "PATH": stubPath},
+			&builder.SwwwCmdBuilder{},
+		},
+		{
+			"the first setter in path",
+			map[string]string{"XDG_CURRENT_DESKTOP": "i3", "PATH": stubPath},
+			&builder.XwallpaperCmdBuilder{},
+		},
+		{
+			"gnome fallback",
+			map[string]string{"PATH": emptyPath},
+			&builder.GnomeCmdBuilder{},
+		},
+	}
+
+	for _, c := range cases {
+		restore := setEnv(t, c.vars)
+		cmdBuilder, err := resolveDesktopEnvironment(config.NewDefault())
+		restore()
+
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, reflect.TypeOf(c.want), reflect.TypeOf(*cmdBuilder), c.name)
+	}
+}
+
+func TestResolveDesktopEnvironment_Forced(t *testing.T) {
+	restore := setEnv(t, map[string]string{"SWAYSOCK": "/run/sway.sock"})
+	defer restore()
+
+	cfg := config.NewDefault()
+	cfg.Desktop.Environment = "feh"
+
+	cmdBuilder, err := resolveDesktopEnvironment(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &builder.FehCmdBuilder{}, *cmdBuilder)
+
+	cfg.Desktop.Environment = "template"
+	cfg.Desktop.Command = []string{"mytool", "{{.Path}}"}
+
+	cmdBuilder, err = resolveDesktopEnvironment(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &builder.TemplateCmdBuilder{}, *cmdBuilder)
+
+	cfg.Desktop.Command = nil
+	_, err = resolveDesktopEnvironment(cfg)
+	assert.Error(t, err)
+
+	cfg.Desktop.Environment = "windows"
+	_, err = resolveDesktopEnvironment(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// secretEnvPrefix is prefix of environment variables
+// holding secrets.
+const secretEnvPrefix = "BLIDER_"
+
+// Secret returns secret (e.g. API key) having specified name.
+// Environment variable named after secret in upper case with
+// "BLIDER_" prefix (e.g. BLIDER_PEXELS_API_KEY for
+// "pexels_api_key") takes precedence over secrets file, which
+// is JSON object mapping names to values. Returns empty string
+// if secret is not set and error if secrets file is malformed.
+func (c *Config) Secret(name string) (string, error) {
+	if value := strings.TrimSpace(os.Getenv(secretEnvPrefix + strings.ToUpper(name))); len(value) > 0 {
+		return value, nil
+	}
+
+	f, err := ioutil.ReadFile(c.SecretsPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(f, &secrets); err != nil {
+		return "", fmt.Errorf("[Parse %s] %v", c.SecretsPath, err)
+	}
+
+	return strings.TrimSpace(secrets[name]), nil
+}
@@ -7,5 +7,5 @@ require (
 	github.com/google/uuid v1.1.1
 	github.com/mattn/go-sqlite3 v2.0.2+incompatible
 	github.com/stretchr/testify v1.4.0
-	golang.org/x/net v0.0.0-20200114155413-6afb5195e5aa // indirect
+	golang.org/x/net v0.0.0-20200114155413-6afb5195e5aa
 )
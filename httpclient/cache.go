@@ -0,0 +1,282 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cacheMetaExt = ".meta"
+	cacheBodyExt = ".body"
+)
+
+// cache is on-disk cache of HTTP responses. Fresh responses are
+// served without requests, stale ones are revalidated using ETag
+// and Last-Modified validators. When cache exceeds size limit,
+// least recently used responses are removed.
+type cache struct {
+	path  string
+	limit int64
+	mutex sync.Mutex
+}
+
+// cacheEntry is metadata of cached response.
+type cacheEntry struct {
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header"`
+	StoredAt time.Time   `json:"stored_at"`
+	body     []byte
+}
+
+func newCache(path string, limit int64) (*cache, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	return &cache{path: path, limit: limit}, nil
+}
+
+// cacheKey identifies response by URL and request headers,
+// so responses to requests with different credentials or
+// preferences don't mix.
+func cacheKey(url string, header http.Header) string {
+	var keys []string
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(url))
+	for _, key := range keys {
+		_, _ = h.Write([]byte("\n" + key + ": " + strings.Join(header[key], ", ")))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// load returns cached response or nil if there is no such.
+func (c *cache) load(key string) *cacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	meta, err := ioutil.ReadFile(filepath.Join(c.path, key+cacheMetaExt))
+	if err != nil {
+		return nil
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(meta, entry); err != nil {
+		return nil
+	}
+
+	bodyPath := filepath.Join(c.path, key+cacheBodyExt)
+	entry.body, err = ioutil.ReadFile(bodyPath)
+	if err != nil {
+		return nil
+	}
+
+	// Modification time of body is time of last use.
+	now := time.Now()
+	_ = os.Chtimes(bodyPath, now, now)
+
+	return entry
+}
+
+// store saves response and removes least recently used ones
+// if cache exceeds size limit.
+func (c *cache) store(key string, entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[Cache %s] %v", entry.URL, err)
+		return
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(c.path, key+cacheBodyExt), entry.body, 0644); err != nil {
+		log.Printf("[Cache %s] %v", entry.URL, err)
+		return
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(c.path, key+cacheMetaExt), meta, 0644); err != nil {
+		log.Printf("[Cache %s] %v", entry.URL, err)
+		return
+	}
+
+	if err := c.cleanUp(); err != nil {
+		log.Printf("[Clean up cache] %v", err)
+	}
+}
+
+// cleanUp removes least recently used responses until
+// cache fits size limit.
+func (c *cache) cleanUp() error {
+	bodies, err := filepath.Glob(filepath.Join(c.path, "*"+cacheBodyExt))
+	if err != nil {
+		return err
+	}
+
+	var infos []os.FileInfo
+	var size int64
+
+	for _, bodyPath := range bodies {
+		info, err := os.Stat(bodyPath)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+		size += info.Size()
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	for _, info := range infos {
+		if size <= c.limit {
+			break
+		}
+
+		key := strings.TrimSuffix(info.Name(), cacheBodyExt)
+		_ = os.Remove(filepath.Join(c.path, key+cacheMetaExt))
+		if err := os.Remove(filepath.Join(c.path, info.Name())); err != nil {
+			return err
+		}
+		size -= info.Size()
+	}
+
+	return nil
+}
+
+// isStorable reports whether response may be cached: it must
+// be successful non-image response that can be revalidated or
+// has explicit freshness lifetime.
+func isStorable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		return false
+	}
+
+	if strings.TrimSpace(resp.Header.Get("Vary")) == "*" {
+		return false
+	}
+
+	directives := cacheControl(resp.Header)
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+
+	_, hasMaxAge := directives["max-age"]
+
+	return len(resp.Header.Get("ETag")) > 0 ||
+		len(resp.Header.Get("Last-Modified")) > 0 ||
+		hasMaxAge ||
+		len(resp.Header.Get("Expires")) > 0
+}
+
+// isFresh reports whether cached response can be used without
+// revalidation at moment now.
+func (e *cacheEntry) isFresh(now time.Time) bool {
+	directives := cacheControl(e.Header)
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+
+	age := now.Sub(e.StoredAt)
+
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		return err == nil && age < time.Duration(seconds)*time.Second
+	}
+
+	expires, err := http.ParseTime(e.Header.Get("Expires"))
+	if err != nil {
+		return false
+	}
+
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.StoredAt
+	}
+
+	return age < expires.Sub(date)
+}
+
+// refresh updates cached response with headers of
+// "304 Not Modified" response received at moment now.
+func (e *cacheEntry) refresh(header http.Header, now time.Time) {
+	for _, key := range []string{"Cache-Control", "Date", "ETag", "Expires", "Last-Modified"} {
+		if values, ok := header[key]; ok {
+			e.Header[key] = values
+		}
+	}
+
+	e.StoredAt = now
+}
+
+// validate adds conditional headers to request header.
+func (e *cacheEntry) validate(header http.Header) {
+	if etag := e.Header.Get("ETag"); len(etag) > 0 {
+		header.Set("If-None-Match", etag)
+	}
+
+	if lastModified := e.Header.Get("Last-Modified"); len(lastModified) > 0 {
+		header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+// response builds response to req from cached one.
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// cacheControl parses Cache-Control header into map of
+// directives to their values.
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(directive), "=", 2)
+			name := strings.ToLower(parts[0])
+			if len(name) == 0 {
+				continue
+			}
+
+			if len(parts) == 2 {
+				directives[name] = strings.Trim(parts[1], `"`)
+			} else {
+				directives[name] = ""
+			}
+		}
+	}
+
+	return directives
+}
@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newCachingClient(t *testing.T, cachePath string) *Client {
+	cfg := config.NewDefault()
+	cfg.HTTP.CachePath = cachePath
+	cfg.HTTP.CacheLimit = 64
+
+	c, err := New(&cfg.HTTP)
+	assert.NoError(t, err)
+
+	return c
+}
+
+func TestClient_GetCached(t *testing.T) {
+	cachePath, err := ioutil.TempDir("", "blider_cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cachePath)
+
+	full, revalidated := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				revalidated++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=3600")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("ETag", `"v1"`)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("ETag", `"v1"`)
+		}
+
+		full++
+		_, _ = fmt.Fprint(w, "page")
+	}))
+	defer server.Close()
+
+	c := newCachingClient(t, cachePath)
+
+	get := func(path string) string {
+		resp, err := c.GetCached(context.Background(), server.URL+path, nil)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		return string(body)
+	}
+
+	// Page is revalidated instead of downloaded again.
+	assert.Equal(t, "page", get("/etag"))
+	assert.Equal(t, "page", get("/etag"))
+	assert.Equal(t, 1, full)
+	assert.Equal(t, 1, revalidated)
+
+	// Fresh page is not requested at all.
+	full = 0
+	assert.Equal(t, "page", get("/fresh"))
+	assert.Equal(t, "page", get("/fresh"))
+	assert.Equal(t, 1, full)
+
+	full = 0
+	get("/no-store")
+	get("/no-store")
+	get("/image")
+	get("/image")
+	assert.Equal(t, 4, full)
+
+	// Plain Get neither uses nor fills cache.
+	full = 0
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(context.Background(), server.URL+"/fresh", nil)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, 2, full)
+}
+
+func TestCache_CleanUp(t *testing.T) {
+	cachePath, err := ioutil.TempDir("", "blider_cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cachePath)
+
+	c, err := newCache(cachePath, 10)
+	assert.NoError(t, err)
+
+	header := http.Header{"Etag": {`"v1"`}}
+	c.store("old", &cacheEntry{URL: "old", Header: header, StoredAt: time.Now(), body: []byte("12345")})
+
+	// Least recently used response is removed first.
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(cachePath, "old"+cacheBodyExt), past, past))
+
+	c.store("new", &cacheEntry{URL: "new", Header: header, StoredAt: time.Now(), body: []byte("123456")})
+
+	assert.Nil(t, c.load("old"))
+	assert.NotNil(t, c.load("new"))
+}
+
+func TestCacheEntry_IsFresh(t *testing.T) {
+	now := time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	entry := &cacheEntry{Header: http.Header{}, StoredAt: now.Add(-time.Minute)}
+	assert.False(t, entry.isFresh(now))
+
+	entry.Header.Set("Cache-Control", "public, max-age=120")
+	assert.True(t, entry.isFresh(now))
+
+	entry.Header.Set("Cache-Control", "max-age=120, no-cache")
+	assert.False(t, entry.isFresh(now))
+
+	entry.Header.Del("Cache-Control")
+	entry.Header.Set("Date", now.Add(-time.Minute).Format(http.TimeFormat))
+	entry.Header.Set("Expires", now.Add(time.Minute).Format(http.TimeFormat))
+	assert.True(t, entry.isFresh(now))
+}
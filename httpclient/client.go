@@ -0,0 +1,367 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBodyTooLarge is returned when response body exceeds
+// configured maximum size.
+var ErrBodyTooLarge = errors.New("response body exceeds size limit")
+
+// Client is HTTP client shared by providers. It sets User-Agent
+// header, retries failed requests with exponential backoff,
+// limits size of response bodies and caches responses requested
+// by GetCached.
+type Client struct {
+	client        *http.Client
+	cache         *cache
+	userAgent     string
+	retries       int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	maxBodySize   int64
+}
+
+// New creates client configured by HTTP section of configuration.
+// Returns error if some of options are malformed.
+func New(options *config.HTTPConfig) (*Client, error) {
+	timeout, err := options.Timeout.ToTime()
+	if err != nil {
+		return nil, fmt.Errorf("[Parse timeout] %v", err)
+	}
+
+	connectTimeout, err := options.ConnectTimeout.ToTime()
+	if err != nil {
+		return nil, fmt.Errorf("[Parse connect timeout] %v", err)
+	}
+
+	retryDelay, err := options.RetryDelay.ToTime()
+	if err != nil {
+		return nil, fmt.Errorf("[Parse retry delay] %v", err)
+	}
+
+	maxRetryDelay, err := options.MaxRetryDelay.ToTime()
+	if err != nil {
+		return nil, fmt.Errorf("[Parse max retry delay] %v", err)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if len(options.Proxy) > 0 {
+		proxyURL, err := parseProxy(options.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("[Parse proxy] %v", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: connectTimeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	retries := options.Retries
+	if retries < 0 {
+		retries = 0
+	}
+
+	c := &Client{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+		userAgent:     options.UserAgent,
+		retries:       retries,
+		retryDelay:    retryDelay,
+		maxRetryDelay: maxRetryDelay,
+		maxBodySize:   options.MaxBodySize,
+	}
+
+	// Client works without cache if cache directory is not available.
+	if options.CacheLimit > 0 {
+		c.cache, err = newCache(options.CachePath, options.CacheLimit)
+		if err != nil {
+			log.Printf("[Init HTTP cache] %v", err)
+		}
+	}
+
+	return c, nil
+}
+
+// parseProxy parses proxy URL and makes sure its scheme
+// is supported by net/http.
+func parseProxy(rawURL string) (*url.URL, error) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		return proxyURL, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme '%s'", proxyURL.Scheme)
+	}
+}
+
+// Get sends GET request with specified headers. Requests failed
+// because of network error, server error (5xx) or rate limit (429)
+// are retried. Delay before retry grows exponentially and is
+// randomized, but server may set it by Retry-After header.
+//
+// Response of the last attempt is returned whatever its status
+// is. Reading response body fails with ErrBodyTooLarge once body
+// exceeds maximum size. Caller must close response body.
+//
+// Responses are never cached, so every request reaches server.
+func (c *Client) Get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	return c.get(ctx, url, header)
+}
+
+// GetCached sends GET request like Get, but successful responses
+// except images are cached according to their Cache-Control, ETag
+// and Last-Modified headers. It's meant for pages that change
+// rarely, not for endpoints giving different result every time.
+func (c *Client) GetCached(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	if c.cache == nil {
+		return c.get(ctx, url, header)
+	}
+
+	key := cacheKey(url, header)
+	entry := c.cache.load(key)
+
+	if entry != nil && entry.isFresh(time.Now()) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("Using cached response of %s", url)
+		return entry.response(req.WithContext(ctx)), nil
+	}
+
+	requestHeader := http.Header{}
+	for key, values := range header {
+		requestHeader[key] = values
+	}
+
+	if entry != nil {
+		entry.validate(requestHeader)
+	}
+
+	resp, err := c.get(ctx, url, requestHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry != nil && resp.StatusCode == http.StatusNotModified {
+		_ = resp.Body.Close()
+		log.Printf("%s has not been modified, using cached response", url)
+
+		entry.refresh(resp.Header, time.Now())
+		c.cache.store(key, entry)
+
+		return entry.response(resp.Request), nil
+	}
+
+	if !isStorable(resp) {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.store(key, &cacheEntry{
+		URL:      url,
+		Header:   resp.Header,
+		StoredAt: time.Now(),
+		body:     body,
+	})
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// get sends request retrying it if needed.
+func (c *Client) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+
+		for key, values := range header {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+
+		if len(req.Header.Get("User-Agent")) == 0 {
+			req.Header.Set("User-Agent", c.userAgent)
+		}
+
+		resp, err := c.client.Do(req)
+		if ctx.Err() != nil || attempt >= c.retries || !shouldRetry(resp, err) {
+			return c.limitBody(resp, err)
+		}
+
+		delay := c.backoff(attempt)
+		reason := ""
+
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+
+			if after, ok := retryAfter(resp, time.Now()); ok {
+				// There is no point to hang waiting for server
+				// asking to come back much later.
+				if after > c.maxRetryDelay {
+					return c.limitBody(resp, nil)
+				}
+				delay = after
+			}
+
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, c.maxBodySize))
+			_ = resp.Body.Close()
+		}
+
+		log.Printf("Request to %s failed (%s), retrying in %s...", url, reason, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether request failed because of reason
+// that may go away on its own.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns randomized delay before retry following
+// specified attempt: half of delay is fixed, half is random.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryDelay
+	for i := 0; i < attempt && delay < c.maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > c.maxRetryDelay {
+		delay = c.maxRetryDelay
+	}
+
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// retryAfter parses Retry-After header which is either number
+// of seconds or HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if date.Before(now) {
+		return 0, true
+	}
+
+	return date.Sub(now), true
+}
+
+// limitBody makes reading of response body fail once body
+// exceeds maximum size. Response with Content-Length exceeding
+// maximum size is rejected at once.
+func (c *Client) limitBody(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ContentLength > c.maxBodySize {
+		_ = resp.Body.Close()
+		return nil, ErrBodyTooLarge
+	}
+
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		remaining:  c.maxBodySize,
+	}
+
+	return resp, nil
+}
+
+// limitedBody is response body returning ErrBodyTooLarge
+// instead of bytes beyond the limit.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// One byte more than remaining is read to find out
+	// whether body exceeds the limit.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrBodyTooLarge
+	}
+
+	return n, err
+}
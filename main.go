@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"github.com/ildarkarymoff/blider/change"
 	"github.com/ildarkarymoff/blider/config"
@@ -8,7 +9,9 @@ import (
 	"github.com/ildarkarymoff/blider/schedule"
 	"log"
 	"os"
+	"os/signal"
 	"path"
+	"syscall"
 )
 
 func main() {
@@ -28,14 +31,31 @@ func main() {
 		log.Fatalf("Failed to load config from %s: %v", *configPath, err)
 	}
 
-	wpProvider := &provider.SimpleDesktopsProvider{}
+	wpProvider, err := provider.NewRegistry(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create providers: %v", err)
+	}
+
 	cmdBuilder, err := change.ResolveBuilder(cfg)
 	if err != nil {
 		log.Fatalf("Failed to resolve cmdBuilder: %v", err)
 	}
 
+	// Daemon is stopped gracefully on interrupt, so download
+	// in progress is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %s, shutting down...", sig)
+		cancel()
+	}()
+
 	scheduler := schedule.NewScheduler(wpProvider, cmdBuilder)
-	if err := scheduler.Start(cfg); err != nil {
+	if err := scheduler.Start(ctx, cfg); err != nil {
 		log.Fatalf("Scheduler error: %v", err)
 	}
 }
@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	apodAPIURL  = "https://api.nasa.gov/planetary/apod"
+	apodPageFmt = "https://apod.nasa.gov/apod/ap%s.html"
+	// apodPublicDomainURL describes usage of NASA images
+	// that have no copyright holder.
+	apodPublicDomainURL = "https://www.nasa.gov/nasa-brand-center/images-and-media/"
+	apodMediaTypeImage  = "image"
+)
+
+// apodFirstDay is the date of the first Astronomy Picture of the Day.
+var apodFirstDay = time.Date(1995, time.June, 16, 0, 0, 0, 0, time.UTC)
+
+// apodLocation is time zone APOD days change in. API rejects
+// dates after today in this time zone.
+var apodLocation = loadApodLocation()
+
+// loadApodLocation loads US Eastern time zone. If time zone
+// database is missing, Eastern Standard Time is used.
+func loadApodLocation() *time.Location {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+
+	return location
+}
+
+// ApodProvider is provider of NASA "Astronomy Picture of
+// the Day" images taken from https://apod.nasa.gov.
+type ApodProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+	apiURL     string
+}
+
+type apodEntry struct {
+	Date      string `json:"date"`
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	HDURL     string `json:"hdurl"`
+	MediaType string `json:"media_type"`
+	Copyright string `json:"copyright"`
+}
+
+func (p *ApodProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing ApodProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "apod", config)
+	p.client = newHTTPClient(config)
+
+	if len(p.apiURL) == 0 {
+		p.apiURL = apodAPIURL
+	}
+}
+
+// Provide walks back day by day starting from today in US
+// Eastern time and downloads the first picture that has not
+// been fetched yet. Days with videos or other non-image media
+// are skipped. Permanent errors (e.g. invalid API key) stop
+// the walk immediately, except for rejected first date: today's
+// picture may be not published yet.
+func (p *ApodProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", p.apiURL)
+
+	date := time.Now().In(apodLocation)
+	attempts := 0
+
+	var lastErr error
+
+	for ; attempts < p.config.Apod.MaxAttempts && !date.Before(apodFirstDay); date = date.AddDate(0, 0, -1) {
+		originURL := fmt.Sprintf(apodPageFmt, date.Format("060102"))
+
+		seen, err := p.seen.isSeen(originURL)
+		if err != nil {
+			return nil, transient(fmt.Sprintf("Check whether %s is seen", originURL), err)
+		}
+
+		if seen {
+			continue
+		}
+
+		attempts++
+
+		entry, err := p.fetchEntry(ctx, date)
+		if err != nil {
+			lastErr = wrap(fmt.Sprintf("Provide %s", originURL), err)
+			if attempts == 1 && statusCodeOf(err) == http.StatusBadRequest {
+				log.Println(lastErr)
+				continue
+			}
+			if ctx.Err() != nil || KindOf(err) == Permanent {
+				return nil, lastErr
+			}
+			log.Println(lastErr)
+			continue
+		}
+
+		if entry.MediaType != apodMediaTypeImage {
+			log.Printf("Skipping %s: media type is '%s'", entry.Date, entry.MediaType)
+			continue
+		}
+
+		imgURL := entry.HDURL
+		if len(imgURL) == 0 {
+			imgURL = entry.URL
+		}
+
+		filename, img, err := downloadImageToBuffer(ctx, p.client, imgURL)
+		if err != nil {
+			lastErr = wrap(fmt.Sprintf("Provide image %s", imgURL), err)
+			if ctx.Err() != nil {
+				return nil, lastErr
+			}
+			log.Println(lastErr)
+			continue
+		}
+
+		// Pictures without copyright are taken by NASA
+		// and belong to public domain.
+		author := strings.Join(strings.Fields(entry.Copyright), " ")
+		authorURL := ""
+		if len(author) == 0 {
+			author = "NASA (public domain)"
+			authorURL = apodPublicDomainURL
+		}
+
+		p.seen.markSeen(originURL)
+
+		return &repository.Wallpaper{
+			OriginURL:      originURL,
+			Filename:       filename,
+			FetchTimestamp: uint(time.Now().Unix()),
+			Title:          entry.Title,
+			Author:         author,
+			AuthorURL:      authorURL,
+			ImgBuffer:      img,
+		}, nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, noMoreContent("Provide", "no picture found after %d attempts", attempts)
+}
+
+func (p *ApodProvider) fetchEntry(ctx context.Context, date time.Time) (*apodEntry, error) {
+	query := url.Values{}
+	query.Set("api_key", p.config.Apod.APIKey)
+	query.Set("date", date.Format("2006-01-02"))
+
+	entryURL := fmt.Sprintf("%s?%s", p.apiURL, query.Encode())
+
+	var entry apodEntry
+	if err := getJSON(ctx, p.client, entryURL, nil, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApodProvider_Provide(t *testing.T) {
+	assert.NoError(t, rep.ClearHistory())
+
+	today := time.Now().In(apodLocation).Format("2006-01-02")
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/apod", func(w http.ResponseWriter, r *http.Request) {
+		date := r.URL.Query().Get("date")
+		if date == today {
+			_, _ = fmt.Fprintf(w, `{
+				"date": "%s",
+				"title": "Comet Flyby",
+				"url": "https://www.youtube.com/embed/comet",
+				"media_type": "video"
+			}`, date)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{
+			"date": "%s",
+			"title": "Andromeda",
+			"url": "%s/image/andromeda.jpg",
+			"hdurl": "%s/image/andromeda_hd.jpg",
+			"media_type": "image",
+			"copyright": "\nJohn\nDoe\n"
+		}`, date, server.URL, server.URL)
+	})
+	mux.HandleFunc("/image/andromeda_hd.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("andromeda"))
+	})
+
+	p := &ApodProvider{apiURL: server.URL + "/apod"}
+	p.Init(config.NewDefault(), rep)
+
+	yesterday := time.Now().In(apodLocation).AddDate(0, 0, -1).Format("060102")
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("andromeda"), wallpaper.ImgBuffer)
+	assert.Equal(t, "Andromeda", wallpaper.Title)
+	assert.Equal(t, "John Doe", wallpaper.Author)
+	assert.Equal(t, fmt.Sprintf(apodPageFmt, yesterday), wallpaper.OriginURL)
+}
+
+func TestApodProvider_ProvideOnlyVideos(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = fmt.Fprint(w, `{"media_type": "video"}`)
+	}))
+	defer server.Close()
+
+	cfg := config.NewDefault()
+	cfg.Apod.MaxAttempts = 3
+
+	p := &ApodProvider{apiURL: server.URL}
+	p.Init(cfg, rep)
+
+	_, err := p.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+	assert.Equal(t, 3, requests)
+}
+
+func TestApodProvider_ProvideTodayNotPublished(t *testing.T) {
+	assert.NoError(t, rep.ClearHistory())
+
+	today := time.Now().In(apodLocation).Format("2006-01-02")
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/apod", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("date") >= today {
+			http.Error(w, `{"msg": "Date must be between Jun 16, 1995 and today."}`, http.StatusBadRequest)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{
+			"title": "Orion",
+			"url": "%s/image/orion.jpg",
+			"media_type": "image"
+		}`, server.URL)
+	})
+	mux.HandleFunc("/image/orion.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("orion"))
+	})
+
+	p := &ApodProvider{apiURL: server.URL + "/apod"}
+	p.Init(config.NewDefault(), rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Orion", wallpaper.Title)
+
+	// Rejected dates other than the first one stop the walk.
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+	})
+
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+}
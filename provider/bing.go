@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	bingURL = "https://www.bing.com"
+	// bingResolutionUHD is resolution of the largest image variant.
+	bingResolutionUHD = "UHD"
+	// bingFallbackResolution is resolution available for every image.
+	bingFallbackResolution = "1920x1080"
+	// bingArchivePageSize is maximum number of images
+	// HPImageArchive returns per request.
+	bingArchivePageSize = 8
+)
+
+// bingAuthorRe extracts author from copyright text
+// like "Lake Bled, Slovenia (© John Doe/Getty Images)".
+var bingAuthorRe = regexp.MustCompile(`\(©\s*([^)]+)\)\s*$`)
+
+// BingProvider is provider of Bing "image of the day"
+// pictures taken from Bing HPImageArchive feed.
+type BingProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+	baseURL    string
+}
+
+type bingArchive struct {
+	Images []*bingImage `json:"images"`
+}
+
+type bingImage struct {
+	StartDate     string `json:"startdate"`
+	URLBase       string `json:"urlbase"`
+	Copyright     string `json:"copyright"`
+	CopyrightLink string `json:"copyrightlink"`
+}
+
+func (p *BingProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing BingProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "bing", config)
+	p.client = newHTTPClient(config)
+
+	if len(p.baseURL) == 0 {
+		p.baseURL = bingURL
+	}
+}
+
+// Provide walks back through last configured number of days
+// and downloads the most recent image that has not been
+// fetched yet.
+func (p *BingProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", p.baseURL)
+
+	options := p.config.Bing
+
+	for idx := 0; idx < options.Days; idx += bingArchivePageSize {
+		n := options.Days - idx
+		if n > bingArchivePageSize {
+			n = bingArchivePageSize
+		}
+
+		images, err := p.fetchArchive(ctx, options.Market, idx, n)
+		if err != nil {
+			return nil, wrap(fmt.Sprintf("Provide %s", p.baseURL), err)
+		}
+
+		if len(images) == 0 {
+			break
+		}
+
+		for _, image := range images {
+			originURL := image.CopyrightLink
+			if len(originURL) == 0 {
+				originURL = fmt.Sprintf("%s%s", p.baseURL, image.URLBase)
+			}
+
+			seen, err := p.seen.isSeen(originURL)
+			if err != nil {
+				return nil, transient(fmt.Sprintf("Check whether %s is seen", image.StartDate), err)
+			}
+
+			if seen {
+				log.Printf("Image of %s has already been fetched", image.StartDate)
+				continue
+			}
+
+			wallpaper, err := p.download(ctx, image, originURL)
+			if err != nil {
+				return nil, err
+			}
+
+			p.seen.markSeen(originURL)
+			return wallpaper, nil
+		}
+	}
+
+	return nil, noMoreContent("Provide", "all images of last %d days have already been fetched", options.Days)
+}
+
+func (p *BingProvider) fetchArchive(
+	ctx context.Context,
+	market string,
+	idx, n int,
+) ([]*bingImage, error) {
+	query := url.Values{}
+	query.Set("format", "js")
+	query.Set("idx", strconv.Itoa(idx))
+	query.Set("n", strconv.Itoa(n))
+	query.Set("mkt", market)
+
+	archiveURL := fmt.Sprintf("%s/HPImageArchive.aspx?%s", p.baseURL, query.Encode())
+	log.Printf("Fetching %s...", archiveURL)
+
+	var archive bingArchive
+	if err := getJSON(ctx, p.client, archiveURL, nil, &archive); err != nil {
+		return nil, err
+	}
+
+	return archive.Images, nil
+}
+
+func (p *BingProvider) download(
+	ctx context.Context,
+	image *bingImage,
+	originURL string,
+) (*repository.Wallpaper, error) {
+	resolutions := []string{p.config.Bing.Resolution}
+	if p.config.Bing.Resolution == bingResolutionUHD {
+		resolutions = append(resolutions, bingFallbackResolution)
+	}
+
+	err := errors.New("no resolutions configured")
+
+	for _, resolution := range resolutions {
+		imgURL := fmt.Sprintf("%s%s_%s.jpg", p.baseURL, image.URLBase, resolution)
+
+		var filename string
+		var img []byte
+		filename, img, err = downloadImageToBuffer(ctx, p.client, imgURL)
+		if err != nil {
+			err = wrap(fmt.Sprintf("Provide image %s", imgURL), err)
+			log.Println(err)
+			continue
+		}
+
+		author := ""
+		if match := bingAuthorRe.FindStringSubmatch(image.Copyright); match != nil {
+			author = strings.TrimSpace(match[1])
+		}
+
+		return &repository.Wallpaper{
+			OriginURL:      originURL,
+			Filename:       filename,
+			FetchTimestamp: uint(time.Now().Unix()),
+			Title:          image.Copyright,
+			Author:         author,
+			ImgBuffer:      img,
+		}, nil
+	}
+
+	return nil, err
+}
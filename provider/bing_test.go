@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBingProvider_Provide(t *testing.T) {
+	assert.NoError(t, rep.ClearHistory())
+
+	var market string
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/HPImageArchive.aspx", func(w http.ResponseWriter, r *http.Request) {
+		market = r.URL.Query().Get("mkt")
+		if r.URL.Query().Get("idx") != "0" {
+			_, _ = fmt.Fprint(w, `{"images": []}`)
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `{"images": [
+			{
+				"startdate": "20200201",
+				"urlbase": "/images/today",
+				"copyright": "Lake Bled, Slovenia (© John Doe/Getty Images)",
+				"copyrightlink": "https://www.bing.com/search?q=bled"
+			},
+			{
+				"startdate": "20200131",
+				"urlbase": "/images/yesterday",
+				"copyright": "Some place",
+				"copyrightlink": "https://www.bing.com/search?q=place"
+			}
+		]}`)
+	})
+	mux.HandleFunc("/images/today_1920x1080.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("today"))
+	})
+	mux.HandleFunc("/images/yesterday_UHD.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("yesterday"))
+	})
+
+	cfg := config.NewDefault()
+	cfg.Bing.Market = "de-DE"
+
+	p := &BingProvider{baseURL: server.URL}
+	p.Init(cfg, rep)
+
+	// UHD variant of today's image is missing, so 1920x1080 is used.
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("today"), wallpaper.ImgBuffer)
+	assert.Equal(t, "Lake Bled, Slovenia (© John Doe/Getty Images)", wallpaper.Title)
+	assert.Equal(t, "John Doe/Getty Images", wallpaper.Author)
+	assert.Equal(t, "https://www.bing.com/search?q=bled", wallpaper.OriginURL)
+	assert.Equal(t, "de-DE", market)
+
+	// Today's image has been marked seen by Provide.
+	seen, err := rep.IsSeen("bing", wallpaper.OriginURL, 0)
+	assert.NoError(t, err)
+	assert.True(t, seen)
+
+	wallpaper, err = p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("yesterday"), wallpaper.ImgBuffer)
+
+	seen, err = rep.IsSeen("bing", wallpaper.OriginURL, 0)
+	assert.NoError(t, err)
+	assert.True(t, seen)
+
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+}
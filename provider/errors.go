@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"fmt"
+)
+
+// ErrorKind tells caller of Provide what to do with error.
+type ErrorKind int
+
+const (
+	// Transient errors (network failures, server errors,
+	// rate limits) may go away on the next attempt.
+	Transient ErrorKind = iota
+	// Permanent errors (missing API key, malformed options,
+	// rejected requests) won't go away until user fixes
+	// configuration.
+	Permanent
+	// NoMoreContent means provider works fine but has
+	// nothing new to give at the moment.
+	NoMoreContent
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case Permanent:
+		return "permanent"
+	case NoMoreContent:
+		return "no more content"
+	default:
+		return "transient"
+	}
+}
+
+// Error is error returned by providers. Op describes what
+// provider was doing when error occurred.
+type Error struct {
+	Kind ErrorKind
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if len(e.Op) == 0 {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("[%s] %v", e.Op, e.Err)
+}
+
+// KindOf returns kind of provider error. Errors not produced
+// by providers are considered transient.
+func KindOf(err error) ErrorKind {
+	if e, ok := err.(*Error); ok {
+		return e.Kind
+	}
+
+	return Transient
+}
+
+// wrap adds operation description to err keeping its kind.
+func wrap(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: KindOf(err), Op: op, Err: err}
+}
+
+func transient(op string, err error) error {
+	return &Error{Kind: Transient, Op: op, Err: err}
+}
+
+func permanent(op string, err error) error {
+	return &Error{Kind: Permanent, Op: op, Err: err}
+}
+
+// noMoreContent returns NoMoreContent error with message
+// formatted according to format specifier.
+func noMoreContent(op string, format string, a ...interface{}) error {
+	return &Error{Kind: NoMoreContent, Op: op, Err: fmt.Errorf(format, a...)}
+}
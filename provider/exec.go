@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// execProtocolVersion is version of JSON document
+	// ExecProvider understands.
+	execProtocolVersion = 1
+	// execStderrLimit is maximum length of command's stderr
+	// included in error message.
+	execStderrLimit = 512
+)
+
+// execResult is JSON document printed by external command.
+// Exactly one of Path and URL must be set unless command
+// reports error.
+type execResult struct {
+	Version   int    `json:"version"`
+	Path      string `json:"path,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Author    string `json:"author,omitempty"`
+	AuthorURL string `json:"author_url,omitempty"`
+	OriginURL string `json:"origin_url,omitempty"`
+	// Error is message of error occurred in command.
+	Error string `json:"error,omitempty"`
+	// ErrorKind is "transient", "permanent" or "no_more_content".
+	// Errors of unknown kind are transient.
+	ErrorKind string `json:"error_kind,omitempty"`
+}
+
+// ExecProvider is provider running user's executable that
+// obtains image and describes it with JSON document printed
+// to stdout.
+type ExecProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	client     *httpclient.Client
+	seen       *seenIndex
+}
+
+func (p *ExecProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing ExecProvider...")
+	p.config = config
+	p.repository = repository
+	p.client = newHTTPClient(config)
+	p.seen = newSeenIndex(repository, "exec", config)
+}
+
+// Provide runs configured command and downloads or reads image
+// it points to. Items already seen are skipped before download.
+func (p *ExecProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	options := p.config.Exec
+	if len(options.Command) == 0 {
+		return nil, permanent("Provide", errors.New("command is not configured"))
+	}
+
+	result, err := p.run(ctx, &options)
+	if err != nil {
+		return nil, err
+	}
+
+	originURL := result.OriginURL
+	if len(originURL) == 0 {
+		if len(result.URL) > 0 {
+			originURL = result.URL
+		} else {
+			originURL = fileURL(result.Path)
+		}
+	}
+
+	seen, err := p.seen.isSeen(originURL)
+	if err != nil {
+		return nil, transient(fmt.Sprintf("Check whether %s is seen", originURL), err)
+	}
+	if seen {
+		return nil, noMoreContent("Provide", "command gave already seen %s", originURL)
+	}
+
+	var filename string
+	var img []byte
+
+	if len(result.URL) > 0 {
+		filename, img, err = downloadImageToBuffer(ctx, p.client, result.URL)
+		if err != nil {
+			return nil, wrap(fmt.Sprintf("Provide %s", result.URL), err)
+		}
+	} else {
+		// Image is copied, so command may remove it
+		// or reuse its path next time.
+		img, err = ioutil.ReadFile(result.Path)
+		if err != nil {
+			return nil, permanent(fmt.Sprintf("Read %s", result.Path), err)
+		}
+		// Command may give different images under the same path,
+		// so each one is stored under its own name.
+		filename = uniqueFilename(filepath.Base(result.Path))
+	}
+
+	p.seen.markSeen(originURL)
+
+	title := result.Title
+	if len(title) == 0 {
+		basename := filepath.Base(result.Path)
+		if u, err := url.Parse(result.URL); err == nil && len(result.URL) > 0 {
+			basename = path.Base(u.Path)
+		}
+		title = strings.TrimSuffix(basename, path.Ext(basename))
+	}
+
+	return &repository.Wallpaper{
+		OriginURL:      originURL,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          title,
+		Author:         result.Author,
+		AuthorURL:      result.AuthorURL,
+		ImgBuffer:      img,
+	}, nil
+}
+
+// run runs command in its directory and parses its output.
+// Relative image path is resolved against command directory.
+// Command is killed when timeout expires or ctx is done.
+func (p *ExecProvider) run(ctx context.Context, options *config.ExecConfig) (*execResult, error) {
+	op := fmt.Sprintf("Run %s", options.Command)
+
+	timeout, err := options.Timeout.ToTime()
+	if err != nil {
+		return nil, permanent("Parse command timeout", err)
+	}
+
+	commandPath, err := exec.LookPath(options.Command)
+	if err == nil {
+		commandPath, err = filepath.Abs(commandPath)
+	}
+	if err != nil {
+		// Command was not found or can't be executed.
+		return nil, permanent(op, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.Command(commandPath, options.Args...)
+	cmd.Dir = filepath.Dir(commandPath)
+	cmd.Env = append(os.Environ(), "BLIDER_PROTOCOL_VERSION="+strconv.Itoa(execProtocolVersion))
+	// Command gets its own process group, so processes it has
+	// started are killed along with it.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.Printf("Running %s...", options.Command)
+
+	if err := runGroup(ctx, cmd); err != nil {
+		if ctx.Err() != nil {
+			return nil, transient(op, fmt.Errorf("command was stopped: %v", ctx.Err()))
+		}
+
+		if _, ok := err.(*exec.ExitError); !ok {
+			// Command was not found or can't be executed.
+			return nil, permanent(op, err)
+		}
+
+		if message := tail(stderr.String(), execStderrLimit); len(message) > 0 {
+			err = fmt.Errorf("%v: %s", err, message)
+		}
+
+		return nil, transient(op, err)
+	}
+
+	result := &execResult{}
+	if err := json.Unmarshal(stdout.Bytes(), result); err != nil {
+		return nil, permanent(op, fmt.Errorf("malformed output: %v", err))
+	}
+
+	if result.Version != execProtocolVersion {
+		return nil, permanent(op, fmt.Errorf(
+			"unsupported protocol version %d, expected %d",
+			result.Version,
+			execProtocolVersion,
+		))
+	}
+
+	if len(result.Error) > 0 {
+		err := errors.New(result.Error)
+
+		switch result.ErrorKind {
+		case "permanent":
+			return nil, permanent(op, err)
+		case "no_more_content":
+			return nil, &Error{Kind: NoMoreContent, Op: op, Err: err}
+		default:
+			return nil, transient(op, err)
+		}
+	}
+
+	if (len(result.Path) > 0) == (len(result.URL) > 0) {
+		return nil, permanent(op, errors.New("exactly one of path and url must be set"))
+	}
+
+	if len(result.Path) > 0 && !filepath.IsAbs(result.Path) {
+		result.Path = filepath.Join(cmd.Dir, result.Path)
+	}
+
+	return result, nil
+}
+
+// runGroup starts cmd and waits for it to finish. Process group
+// of cmd is killed as soon as ctx is done.
+func runGroup(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-done:
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+// tail returns at most limit last bytes of trimmed s.
+func tail(s string, limit int) string {
+	s = strings.TrimSpace(s)
+	if len(s) > limit {
+		s = "..." + s[len(s)-limit:]
+	}
+
+	return s
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeScript creates shell script printing output to stdout.
+func writeScript(t *testing.T, dir, name, body string) string {
+	script := filepath.Join(dir, name)
+	err := ioutil.WriteFile(script, []byte("#!/bin/sh\n"+body+"\n"), 0755)
+	assert.NoError(t, err)
+	return script
+}
+
+func TestExecProvider_Provide(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blider_exec_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	imgPath := filepath.Join(dir, "comet.png")
+	assert.NoError(t, ioutil.WriteFile(imgPath, []byte("comet"), 0644))
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write([]byte("nebula"))
+	}))
+	defer server.Close()
+
+	cfg := config.NewDefault()
+	cfg.Exec.Command = writeScript(t, dir, "path.sh", fmt.Sprintf(
+		`echo '{"version": '$BLIDER_PROTOCOL_VERSION', "path": "%s", "author": "Ann", "origin_url": "https://example.com/comet"}'`,
+		imgPath,
+	))
+
+	p := &ExecProvider{}
+	p.Init(cfg, rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("comet"), wallpaper.ImgBuffer)
+	assert.Regexp(t, `-comet\.png$`, wallpaper.Filename)
+	assert.Equal(t, "comet", wallpaper.Title)
+	assert.Equal(t, "Ann", wallpaper.Author)
+	assert.Equal(t, "https://example.com/comet", wallpaper.OriginURL)
+
+	// The same item is not given twice.
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+
+	cfg.Exec.Command = writeScript(t, dir, "url.sh", fmt.Sprintf(
+		`echo '{"version": 1, "url": "%s/nebula.jpg", "title": "Nebula"}'`,
+		server.URL,
+	))
+
+	wallpaper, err = p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("nebula"), wallpaper.ImgBuffer)
+	assert.Equal(t, "Nebula", wallpaper.Title)
+	assert.Equal(t, server.URL+"/nebula.jpg", wallpaper.OriginURL)
+
+	// Already seen item is not downloaded again.
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+	assert.Equal(t, 1, downloads)
+
+	// Relative path is resolved against command directory.
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "out"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "out", "moon.png"), []byte("moon"), 0644))
+	cfg.Exec.Command = writeScript(t, dir, "relative.sh", `echo '{"version": 1, "path": "out/moon.png"}'`)
+
+	wallpaper, err = p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("moon"), wallpaper.ImgBuffer)
+	assert.Equal(t, "file://"+filepath.Join(dir, "out", "moon.png"), wallpaper.OriginURL)
+	assert.Equal(t, "moon", wallpaper.Title)
+
+	// Images given under the same path are stored under different names.
+	cfg.Exec.Command = writeScript(t, dir, "same_path.sh", `echo '{"version": 1, "path": "out/moon.png", "origin_url": "https://example.com/moon2"}'`)
+
+	next, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Regexp(t, `-moon\.png$`, next.Filename)
+	assert.NotEqual(t, wallpaper.Filename, next.Filename)
+}
+
+func TestExecProvider_ProvideErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blider_exec_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := config.NewDefault()
+	cfg.Exec.Timeout = "1s"
+
+	p := &ExecProvider{}
+	p.Init(cfg, nil)
+
+	cases := []struct {
+		name   string
+		script string
+		kind   ErrorKind
+	}{
+		{"exhausted", `echo '{"version": 1, "error": "nothing left", "error_kind": "no_more_content"}'`, NoMoreContent},
+		{"failed", `echo 'network is down' >&2; exit 1`, Transient},
+		{"version", `echo '{"version": 2, "path": "/tmp/a.png"}'`, Permanent},
+		{"malformed", `echo 'not json'`, Permanent},
+		{"both", `echo '{"version": 1, "path": "/tmp/a.png", "url": "http://a/b.png"}'`, Permanent},
+		{"slow", `sleep 5`, Transient},
+	}
+
+	for _, c := range cases {
+		cfg.Exec.Command = writeScript(t, dir, c.name+".sh", c.script)
+
+		_, err := p.Provide(context.Background())
+		assert.Equal(t, c.kind, KindOf(err), "%s: %v", c.name, err)
+	}
+
+	cfg.Exec.Command = filepath.Join(dir, "missing")
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+}
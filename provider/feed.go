@@ -0,0 +1,364 @@
+package provider
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"golang.org/x/net/html/charset"
+	"log"
+	"math/rand"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// FeedProvider is provider of images taken from RSS 2.0,
+// RSS 1.0, Atom and Media RSS feeds.
+type FeedProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+}
+
+// feedDocument is union of RSS and Atom documents.
+type feedDocument struct {
+	Channel struct {
+		Items []*feedItem `xml:"item"`
+	} `xml:"channel"`
+	// Items are RSS 1.0 (RDF) items placed next to channel.
+	Items []*feedItem `xml:"item"`
+	// Entries are Atom entries.
+	Entries []*feedItem `xml:"entry"`
+}
+
+// feedItem is union of RSS item and Atom entry.
+type feedItem struct {
+	// MediaTitle and MediaDescription absorb Media RSS elements
+	// so they don't override item's own title and description.
+	MediaTitle       string `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription string `xml:"http://search.yahoo.com/mrss/ description"`
+
+	Title       string           `xml:"title"`
+	Links       []*feedLink      `xml:"link"`
+	Author      *feedAuthor      `xml:"author"`
+	Creator     string           `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Enclosures  []*feedEnclosure `xml:"enclosure"`
+	Description string           `xml:"description"`
+	Encoded     string           `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Content     string           `xml:"http://www.w3.org/2005/Atom content"`
+	Summary     string           `xml:"http://www.w3.org/2005/Atom summary"`
+
+	MediaContents   []*feedMedia `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnails []*feedMedia `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroups     []struct {
+		Contents   []*feedMedia `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnails []*feedMedia `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// feedLink is RSS link (text) or Atom link (attributes).
+type feedLink struct {
+	Text   string `xml:",chardata"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+// feedAuthor is RSS author (text) or Atom person construct.
+type feedAuthor struct {
+	Text string `xml:",chardata"`
+	Name string `xml:"name"`
+	URI  string `xml:"uri"`
+}
+
+type feedEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type feedMedia struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+	Width    int    `xml:"width,attr"`
+	Height   int    `xml:"height,attr"`
+	FileSize int64  `xml:"fileSize,attr"`
+}
+
+// feedImage is image variant found in feed item.
+type feedImage struct {
+	url    string
+	width  int
+	height int
+	size   int64
+}
+
+// larger reports whether image i is larger than image other.
+// Images are compared by area and then by file size.
+func (i *feedImage) larger(other *feedImage) bool {
+	area, otherArea := i.width*i.height, other.width*other.height
+	if area != otherArea {
+		return area > otherArea
+	}
+
+	return i.size > other.size
+}
+
+func (p *FeedProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing FeedProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "feed", config)
+	p.client = newHTTPClient(config)
+}
+
+// Provide reads configured feeds and downloads the largest
+// image of random item that has not been fetched yet.
+func (p *FeedProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	type candidate struct {
+		item  *feedItem
+		link  string
+		image *feedImage
+	}
+
+	var candidates []*candidate
+	var lastErr error
+
+	for _, feedURL := range p.config.Feed.URLs {
+		log.Printf("Fetching %s...", feedURL)
+
+		items, err := fetchFeed(ctx, p.client, feedURL)
+		if err != nil {
+			lastErr = wrap(fmt.Sprintf("Provide %s", feedURL), err)
+			if ctx.Err() != nil {
+				return nil, lastErr
+			}
+			log.Println(lastErr)
+			continue
+		}
+
+		for _, item := range items {
+			link := item.link()
+			image := item.largestImage(link, feedURL)
+			if image == nil {
+				continue
+			}
+
+			if len(link) == 0 {
+				link = image.url
+			}
+
+			seen, err := p.seen.isSeen(link)
+			if err != nil {
+				return nil, transient(fmt.Sprintf("Check whether %s is seen", link), err)
+			}
+
+			if !seen {
+				candidates = append(candidates, &candidate{item, link, image})
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, noMoreContent("Provide", "no new images found in feeds")
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	selected := candidates[rand.Intn(len(candidates))]
+
+	filename, img, err := downloadImageToBuffer(ctx, p.client, selected.image.url)
+	if err != nil {
+		return nil, wrap(fmt.Sprintf("Provide image %s", selected.image.url), err)
+	}
+
+	p.seen.markSeen(selected.link)
+
+	author, authorURL := selected.item.author()
+
+	return &repository.Wallpaper{
+		OriginURL:      selected.link,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          strings.TrimSpace(selected.item.Title),
+		Author:         author,
+		AuthorURL:      authorURL,
+		ImgBuffer:      img,
+	}, nil
+}
+
+func fetchFeed(
+	ctx context.Context,
+	client *httpclient.Client,
+	feedURL string,
+) ([]*feedItem, error) {
+	resp, err := get(ctx, client, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Feeds are often encoded in legacy charsets
+	// (e.g. ISO-8859-1 or windows-1251).
+	decoder := xml.NewDecoder(resp.Body)
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	var doc feedDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, bodyError(err)
+	}
+
+	items := append(doc.Channel.Items, doc.Items...)
+	return append(items, doc.Entries...), nil
+}
+
+// link returns address of item's web page.
+func (i *feedItem) link() string {
+	for _, l := range i.Links {
+		if len(l.Href) == 0 {
+			if text := strings.TrimSpace(l.Text); len(text) > 0 {
+				return text
+			}
+			continue
+		}
+
+		if len(l.Rel) == 0 || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+
+	return ""
+}
+
+func (i *feedItem) author() (string, string) {
+	if len(strings.TrimSpace(i.Creator)) > 0 {
+		return strings.TrimSpace(i.Creator), ""
+	}
+
+	if i.Author == nil {
+		return "", ""
+	}
+
+	if len(strings.TrimSpace(i.Author.Name)) > 0 {
+		return strings.TrimSpace(i.Author.Name), strings.TrimSpace(i.Author.URI)
+	}
+
+	return strings.TrimSpace(i.Author.Text), ""
+}
+
+// largestImage collects images from enclosures, Media RSS elements and
+// item content and returns the largest one. Relative URLs are resolved
+// against item link or feed URL. Returns nil if item has no images.
+func (i *feedItem) largestImage(link, feedURL string) *feedImage {
+	var images []*feedImage
+
+	for _, e := range i.Enclosures {
+		if isImageMedia(e.URL, e.Type, "") {
+			images = append(images, &feedImage{url: e.URL, size: e.Length})
+		}
+	}
+
+	for _, l := range i.Links {
+		if l.Rel == "enclosure" && isImageMedia(l.Href, l.Type, "") {
+			images = append(images, &feedImage{url: l.Href, size: l.Length})
+		}
+	}
+
+	media := append(i.MediaContents, i.MediaThumbnails...)
+	for _, group := range i.MediaGroups {
+		media = append(media, group.Contents...)
+		media = append(media, group.Thumbnails...)
+	}
+
+	for _, m := range media {
+		if isImageMedia(m.URL, m.Type, m.Medium) {
+			images = append(images, &feedImage{
+				url:    m.URL,
+				width:  m.Width,
+				height: m.Height,
+				size:   m.FileSize,
+			})
+		}
+	}
+
+	if len(images) == 0 {
+		for _, html := range []string{i.Encoded, i.Content, i.Description, i.Summary} {
+			if src := firstImgSrc(html); len(src) > 0 {
+				images = append(images, &feedImage{url: src})
+				break
+			}
+		}
+	}
+
+	var largest *feedImage
+	for _, image := range images {
+		if largest == nil || image.larger(largest) {
+			largest = image
+		}
+	}
+
+	if largest == nil {
+		return nil
+	}
+
+	base := link
+	if len(base) == 0 {
+		base = feedURL
+	}
+
+	largest.url = resolveURL(base, largest.url)
+	return largest
+}
+
+// isImageMedia guesses whether media element points to image
+// by its medium, MIME type or URL extension.
+func isImageMedia(mediaURL, mimeType, medium string) bool {
+	if len(mediaURL) == 0 {
+		return false
+	}
+
+	if len(medium) > 0 {
+		return medium == "image"
+	}
+
+	if len(mimeType) > 0 {
+		return strings.HasPrefix(mimeType, "image/")
+	}
+
+	u, err := url.Parse(mediaURL)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".jpg", ".jpeg", ".png", ".webp":
+		return true
+	}
+
+	return false
+}
+
+// firstImgSrc returns src attribute of the first <img> in HTML.
+func firstImgSrc(html string) string {
+	if len(strings.TrimSpace(html)) == 0 {
+		return ""
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return ""
+	}
+
+	src, _ := doc.Find("img").First().Attr("src")
+	return strings.TrimSpace(src)
+}
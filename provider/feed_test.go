@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel>
+		<title>Photos</title>
+		<item>
+			<title>Misty forest</title>
+			<link>https://photos.example.org/misty-forest</link>
+			<dc:creator>Jane Doe</dc:creator>
+			<media:title>Ignored media title</media:title>
+			<media:group>
+				<media:content url="%[1]s/images/forest_small.jpg" medium="image" width="640" height="480"/>
+				<media:content url="%[1]s/images/forest_large.jpg" medium="image" width="3840" height="2160"/>
+				<media:content url="%[1]s/videos/forest.mp4" medium="video" width="7680" height="4320"/>
+			</media:group>
+			<media:thumbnail url="%[1]s/images/forest_thumb.jpg" width="150" height="100"/>
+		</item>
+	</channel>
+</rss>`
+
+	testAtomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Blog</title>
+	<entry>
+		<title>Sunset at sea</title>
+		<link rel="alternate" href="https://blog.example.org/posts/sunset"/>
+		<author>
+			<name>John Doe</name>
+			<uri>https://blog.example.org/about</uri>
+		</author>
+		<content type="html">&lt;p&gt;Look:&lt;/p&gt;&lt;img src="/media/sunset.jpg"&gt;</content>
+	</entry>
+</feed>`
+)
+
+func TestFeedProvider_Provide(t *testing.T) {
+	assert.NoError(t, rep.ClearHistory())
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/rss.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, testRSSFeed, server.URL)
+	})
+	mux.HandleFunc("/images/forest_large.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("forest"))
+	})
+
+	cfg := config.NewDefault()
+	cfg.Feed.URLs = []string{server.URL + "/rss.xml", server.URL + "/missing.xml"}
+
+	p := &FeedProvider{}
+	p.Init(cfg, rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("forest"), wallpaper.ImgBuffer)
+	assert.Equal(t, "Misty forest", wallpaper.Title)
+	assert.Equal(t, "Jane Doe", wallpaper.Author)
+	assert.Equal(t, "https://photos.example.org/misty-forest", wallpaper.OriginURL)
+
+	// The only item has been marked seen by Provide.
+	seen, err := rep.IsSeen("feed", wallpaper.OriginURL, 0)
+	assert.NoError(t, err)
+	assert.True(t, seen)
+
+	// Failure of missing feed is reported instead of lack of new images.
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+}
+
+func TestFeedItem_Atom(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, testAtomFeed)
+	}))
+	defer server.Close()
+
+	items, err := fetchFeed(context.Background(), newHTTPClient(config.NewDefault()), server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	item := items[0]
+	link := item.link()
+	assert.Equal(t, "https://blog.example.org/posts/sunset", link)
+	assert.Equal(t, "https://blog.example.org/media/sunset.jpg", item.largestImage(link, server.URL).url)
+
+	author, authorURL := item.author()
+	assert.Equal(t, "John Doe", author)
+	assert.Equal(t, "https://blog.example.org/about", authorURL)
+}
+
+func TestFetchFeed_Charset(t *testing.T) {
+	feeds := map[string]string{
+		"/latin1.xml": "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+			"<rss version=\"2.0\"><channel><item><title>Caf\xe9</title></item></channel></rss>",
+		"/cyrillic.xml": "<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n" +
+			"<rss version=\"2.0\"><channel><item><title>\xcb\xe5\xf1</title></item></channel></rss>",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(feeds[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(config.NewDefault())
+
+	items, err := fetchFeed(context.Background(), client, server.URL+"/latin1.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, "Café", items[0].Title)
+
+	items, err = fetchFeed(context.Background(), client, server.URL+"/cyrillic.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, "Лес", items[0].Title)
+}
@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/google/uuid"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// imageExtensions maps image MIME types to file extensions
+// used when downloaded image URL has no extension.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// newHTTPClient creates HTTP client configured by http section
+// of config. Malformed options are logged and nil is returned,
+// so requests of provider fail with permanent error.
+func newHTTPClient(config *config.Config) *httpclient.Client {
+	client, err := httpclient.New(&config.HTTP)
+	if err != nil {
+		log.Printf("[Init HTTP client] %v", err)
+		return nil
+	}
+
+	return client
+}
+
+// get sends GET request with specified headers and makes sure
+// response status is 200 OK. Caller must close response body.
+// Network failures and server errors are transient, other
+// unexpected statuses are permanent.
+func get(
+	ctx context.Context,
+	client *httpclient.Client,
+	url string,
+	header http.Header,
+) (*http.Response, error) {
+	if client == nil {
+		return nil, errNoClient
+	}
+
+	return checkResponse(client.Get(ctx, url, header))
+}
+
+// getCached is get using HTTP cache. It's used for pages that
+// change rarely, so it must not be used for random endpoints
+// giving different result every time.
+func getCached(
+	ctx context.Context,
+	client *httpclient.Client,
+	url string,
+	header http.Header,
+) (*http.Response, error) {
+	if client == nil {
+		return nil, errNoClient
+	}
+
+	return checkResponse(client.GetCached(ctx, url, header))
+}
+
+// errNoClient is returned when provider has no HTTP client.
+var errNoClient = permanent("", errors.New("HTTP client is not configured"))
+
+// checkResponse makes sure response status is 200 OK.
+func checkResponse(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return nil, bodyError(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, statusError(resp)
+	}
+
+	return resp, nil
+}
+
+func statusError(resp *http.Response) error {
+	err := &httpStatusError{status: resp.Status, code: resp.StatusCode}
+
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout,
+		resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= http.StatusInternalServerError:
+		return transient("", err)
+	default:
+		return permanent("", err)
+	}
+}
+
+// httpStatusError is error of unexpected response status.
+type httpStatusError struct {
+	status string
+	code   int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected response status: %s", e.status)
+}
+
+// statusCodeOf returns status code of response err is caused
+// by or zero if err is not caused by unexpected status.
+func statusCodeOf(err error) int {
+	for {
+		switch e := err.(type) {
+		case *Error:
+			err = e.Err
+		case *httpStatusError:
+			return e.code
+		default:
+			return 0
+		}
+	}
+}
+
+// bodyError classifies error of reading response: too large
+// body is permanent error, others are transient.
+func bodyError(err error) error {
+	if err == httpclient.ErrBodyTooLarge {
+		return permanent("", err)
+	}
+
+	return transient("", err)
+}
+
+// getJSON requests url and decodes JSON response body into v.
+// Malformed response is transient error.
+func getJSON(
+	ctx context.Context,
+	client *httpclient.Client,
+	url string,
+	header http.Header,
+	v interface{},
+) error {
+	resp, err := get(ctx, client, url, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return bodyError(err)
+	}
+
+	return nil
+}
+
+// getDocument requests url and parses HTML response body.
+// Scraped list and wallpaper pages change rarely, so they
+// are cached.
+func getDocument(ctx context.Context, client *httpclient.Client, url string) (*goquery.Document, error) {
+	resp, err := getCached(ctx, client, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, bodyError(err)
+	}
+
+	return doc, nil
+}
+
+// resolveURL resolves possibly relative reference ref against base URL.
+func resolveURL(base, ref string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	return baseURL.ResolveReference(refURL).String()
+}
+
+func downloadImageToBuffer(
+	ctx context.Context,
+	client *httpclient.Client,
+	url string,
+) (string, []byte, error) {
+	resp, err := get(ctx, client, url, nil)
+	if err != nil {
+		return "", []byte{}, err
+	}
+	defer resp.Body.Close()
+
+	basename := path.Base(resp.Request.URL.Path)
+	if len(path.Ext(basename)) == 0 {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		basename += imageExtensions[mediaType]
+	}
+
+	filename := uniqueFilename(basename)
+
+	img, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", []byte{}, bodyError(err)
+	}
+
+	imgSize := float32(len(img))
+
+	log.Printf(
+		"Downloaded '%s' / %.2f KB",
+		filename,
+		imgSize/1024,
+	)
+	return filename, img, nil
+}
+
+// uniqueFilename prefixes basename with UUID, so images having
+// the same name don't overwrite each other in local storage.
+func uniqueFilename(basename string) string {
+	return fmt.Sprintf("%s-%s", uuid.New().String(), basename)
+}
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	generatorScheme = "generator"
+	// drmPath is directory where kernel exposes connected displays.
+	drmPath = "/sys/class/drm"
+
+	defaultGeneratorWidth  = 1920
+	defaultGeneratorHeight = 1080
+)
+
+// generatorStyle renders image of specific style using rng
+// as the only source of randomness.
+type generatorStyle struct {
+	title  string
+	render func(rng *rand.Rand, img *image.RGBA)
+}
+
+var generatorStyles = map[string]*generatorStyle{
+	"linear":  {"Linear gradient", renderLinearGradient},
+	"radial":  {"Radial gradient", renderRadialGradient},
+	"noise":   {"Noise", renderNoise},
+	"lowpoly": {"Low poly", renderLowPoly},
+	"stripes": {"Stripes", renderStripes},
+}
+
+// GeneratorProvider is provider of procedurally generated
+// images. Images are rendered offline, so generator is also
+// useful as a fallback when network is unavailable.
+type GeneratorProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+}
+
+func (p *GeneratorProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing GeneratorProvider...")
+	p.config = config
+	p.repository = repository
+}
+
+// Provide renders PNG image of random configured style. Style
+// and seed are recorded in OriginURL, e.g. "generator://noise?seed=42".
+func (p *GeneratorProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	options := p.config.Generator
+
+	styles := options.Styles
+	if len(styles) == 0 {
+		for name := range generatorStyles {
+			styles = append(styles, name)
+		}
+		sort.Strings(styles)
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	styleName := strings.ToLower(strings.TrimSpace(styles[rand.Intn(len(styles))]))
+
+	style, ok := generatorStyles[styleName]
+	if !ok {
+		return nil, permanent("Provide", fmt.Errorf("unknown generator style '%s'", styleName))
+	}
+
+	seed := options.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+
+	width, height := options.Width, options.Height
+	if width <= 0 || height <= 0 {
+		width, height = detectResolution()
+	}
+
+	log.Printf("Generating %s image %dx%d with seed %d...", styleName, width, height, seed)
+
+	img, err := generate(style, seed, width, height)
+	if err != nil {
+		return nil, permanent(fmt.Sprintf("Provide %s image", styleName), err)
+	}
+
+	// Rendering can't be interrupted, but there is no point
+	// to give image nobody is waiting for.
+	if ctx.Err() != nil {
+		return nil, transient("Provide", ctx.Err())
+	}
+
+	query := url.Values{}
+	query.Set("seed", strconv.FormatInt(seed, 10))
+	query.Set("width", strconv.Itoa(width))
+	query.Set("height", strconv.Itoa(height))
+
+	originURL := &url.URL{
+		Scheme:   generatorScheme,
+		Host:     styleName,
+		RawQuery: query.Encode(),
+	}
+
+	// Fixed seed renders the same image on every change, but
+	// each render is stored under its own name, so history rows
+	// never share image file.
+	return &repository.Wallpaper{
+		OriginURL:      originURL.String(),
+		Filename:       uniqueFilename(fmt.Sprintf("%s-%d.png", styleName, seed)),
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          fmt.Sprintf("%s #%d", style.title, seed),
+		Author:         "blider",
+		ImgBuffer:      img,
+	}, nil
+}
+
+// generate renders image of specified style and encodes it to PNG.
+// The same seed always gives the same image.
+func generate(style *generatorStyle, seed int64, width, height int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	style.render(rand.New(rand.NewSource(seed)), img)
+
+	var buf bytes.Buffer
+	encoder := &png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// detectResolution returns resolution of the largest connected
+// display or 1920x1080 if it can't be detected.
+func detectResolution() (int, int) {
+	width, height := defaultGeneratorWidth, defaultGeneratorHeight
+
+	statuses, _ := filepath.Glob(filepath.Join(drmPath, "*", "status"))
+	detected := false
+
+	for _, statusPath := range statuses {
+		status, err := ioutil.ReadFile(statusPath)
+		if err != nil || strings.TrimSpace(string(status)) != "connected" {
+			continue
+		}
+
+		modes, err := ioutil.ReadFile(filepath.Join(filepath.Dir(statusPath), "modes"))
+		if err != nil {
+			continue
+		}
+
+		// The first mode is the preferred one, e.g. "2560x1440".
+		mode := strings.SplitN(strings.TrimSpace(string(modes)), "\n", 2)[0]
+		size := strings.SplitN(mode, "x", 2)
+		if len(size) != 2 {
+			continue
+		}
+
+		w, errW := strconv.Atoi(size[0])
+		h, errH := strconv.Atoi(strings.TrimRight(size[1], "i"))
+		if errW != nil || errH != nil {
+			continue
+		}
+
+		if !detected || w*h > width*height {
+			width, height = w, h
+			detected = true
+		}
+	}
+
+	return width, height
+}
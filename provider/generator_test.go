@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	for name, style := range generatorStyles {
+		first, err := generate(style, 42, 64, 36)
+		assert.NoError(t, err, name)
+
+		second, err := generate(style, 42, 64, 36)
+		assert.NoError(t, err, name)
+		assert.Equal(t, first, second, "%s image must be deterministic", name)
+
+		other, err := generate(style, 43, 64, 36)
+		assert.NoError(t, err, name)
+		assert.NotEqual(t, first, other, "%s images of different seeds must differ", name)
+
+		img, err := png.Decode(bytes.NewReader(first))
+		assert.NoError(t, err, name)
+		assert.Equal(t, 64, img.Bounds().Dx())
+		assert.Equal(t, 36, img.Bounds().Dy())
+	}
+}
+
+func TestGeneratorProvider_Provide(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Generator.Width = 32
+	cfg.Generator.Height = 18
+	cfg.Generator.Styles = []string{"lowpoly"}
+	cfg.Generator.Seed = 7
+
+	p := &GeneratorProvider{}
+	p.Init(cfg, rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, wallpaper.ImgBuffer)
+	assert.Equal(t, "generator://lowpoly?height=18&seed=7&width=32", wallpaper.OriginURL)
+	assert.Regexp(t, `-lowpoly-7\.png$`, wallpaper.Filename)
+	assert.Equal(t, "Low poly #7", wallpaper.Title)
+
+	// The same seed regenerates the same image.
+	again, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, wallpaper.ImgBuffer, again.ImgBuffer)
+	assert.NotEqual(t, wallpaper.Filename, again.Filename)
+
+	cfg.Generator.Styles = []string{"unknown"}
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+}
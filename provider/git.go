@@ -0,0 +1,322 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// gitMetadataFile is file in repository root mapping image
+	// paths relative to root to their metadata.
+	gitMetadataFile = "metadata.json"
+	// gitSidecarExt is extension of image sidecar file having
+	// metadata in front matter, e.g. "forest.md" for "forest.jpg".
+	gitSidecarExt = ".md"
+	// frontMatterDelimiter opens and closes front matter.
+	frontMatterDelimiter = "---"
+)
+
+// GitProvider is provider of images picked from git repository,
+// e.g. wallpaper collection shared by team. Repository is cloned
+// into checkouts directory and pulled periodically.
+type GitProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	// checkoutPath is path to working tree of repository.
+	checkoutPath string
+	pullInterval time.Duration
+	// pulledAt is a time repository was cloned or pulled last
+	// time. Zero time means it has to be done on next change.
+	pulledAt time.Time
+}
+
+// gitMetadata describes image of repository.
+type gitMetadata struct {
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	AuthorURL string `json:"author_url"`
+}
+
+func (p *GitProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing GitProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "git", config)
+	p.checkoutPath = filepath.Join(config.Git.CheckoutsPath, checkoutName(config.Git.URL))
+	p.pulledAt = time.Time{}
+
+	var err error
+	p.pullInterval, err = config.Git.PullInterval.ToTime()
+	if err != nil {
+		log.Printf("[Parse pull interval] %v", err)
+		p.pullInterval = time.Hour
+	}
+}
+
+// checkoutName returns name of directory repository is cloned
+// into. Hash of URL keeps different repositories having the same
+// name apart.
+func checkoutName(url string) string {
+	name := strings.TrimSuffix(path.Base(strings.TrimRight(url, "/")), ".git")
+	hash := sha1.Sum([]byte(url))
+
+	return fmt.Sprintf("%s-%s", name, hex.EncodeToString(hash[:])[:8])
+}
+
+// Provide updates repository if pull interval has passed and
+// picks random image not seen yet from it. Once all images have
+// been seen, it starts over.
+func (p *GitProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	options := p.config.Git
+	if len(options.URL) == 0 {
+		return nil, permanent("Provide", errors.New("repository URL is not configured"))
+	}
+
+	if err := p.update(ctx); err != nil {
+		return nil, err
+	}
+
+	images, err := findImages(ctx, []string{p.checkoutPath}, options.Extensions, options.Patterns)
+	if err != nil {
+		return nil, transient("Provide", err)
+	}
+
+	if len(images) == 0 {
+		return nil, noMoreContent("Provide", "no images found in repository")
+	}
+
+	imgPath, err := p.seen.pickUnseenFile(images, p.originURL)
+	if err != nil {
+		return nil, wrap("Provide", err)
+	}
+	log.Printf("Picked %s", imgPath)
+
+	// Image is copied, so it's kept even if next pull removes it.
+	img, err := ioutil.ReadFile(imgPath)
+	if err != nil {
+		return nil, transient(fmt.Sprintf("Read %s", imgPath), err)
+	}
+
+	// Images in different folders may have the same name,
+	// so each one is stored under its own name.
+	basename := filepath.Base(imgPath)
+	filename := uniqueFilename(basename)
+	metadata := p.metadata(imgPath)
+
+	title := metadata.Title
+	if len(title) == 0 {
+		title = strings.TrimSuffix(basename, filepath.Ext(basename))
+	}
+
+	originURL := p.originURL(imgPath)
+	p.seen.markSeen(originURL)
+
+	return &repository.Wallpaper{
+		OriginURL:      originURL,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          title,
+		Author:         metadata.Author,
+		AuthorURL:      metadata.AuthorURL,
+		ImgBuffer:      img,
+	}, nil
+}
+
+// originURL returns repository URL with image path relative
+// to repository root as fragment.
+func (p *GitProvider) originURL(imgPath string) string {
+	return fmt.Sprintf("%s#%s", p.config.Git.URL, p.relativePath(imgPath))
+}
+
+// relativePath returns slash-separated path of image relative
+// to repository root.
+func (p *GitProvider) relativePath(imgPath string) string {
+	rel, err := filepath.Rel(p.checkoutPath, imgPath)
+	if err != nil {
+		return filepath.ToSlash(imgPath)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// update clones repository if there is no checkout yet or pulls
+// it if pull interval has passed. Failed pull is only logged, so
+// the last good checkout keeps being used.
+func (p *GitProvider) update(ctx context.Context) error {
+	if time.Since(p.pulledAt) < p.pullInterval {
+		return nil
+	}
+
+	options := p.config.Git
+
+	if _, err := os.Stat(filepath.Join(p.checkoutPath, ".git")); err != nil {
+		log.Printf("Cloning %s into %s...", options.URL, p.checkoutPath)
+
+		if err := p.clone(ctx); err != nil {
+			return transient(fmt.Sprintf("Clone %s", options.URL), err)
+		}
+
+		p.pulledAt = time.Now()
+		return nil
+	}
+
+	log.Printf("Pulling %s...", options.URL)
+
+	args := []string{"-C", p.checkoutPath, "pull", "--ff-only", "--quiet"}
+	if len(options.Branch) > 0 {
+		args = append(args, "origin", options.Branch)
+	}
+
+	if err := runGit(ctx, args...); err != nil {
+		if ctx.Err() != nil {
+			return transient(fmt.Sprintf("Pull %s", options.URL), err)
+		}
+
+		log.Printf("[Pull %s] %v", options.URL, err)
+		log.Println("Using the last good checkout")
+	}
+
+	// Failed pull is not retried until next interval either,
+	// there is no point to hammer remote on each change.
+	p.pulledAt = time.Now()
+	return nil
+}
+
+// clone clones repository into temporary directory first, so
+// failed clone doesn't leave broken checkout behind.
+func (p *GitProvider) clone(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(p.checkoutPath), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := p.checkoutPath + ".tmp"
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return err
+	}
+
+	args := []string{"clone", "--quiet"}
+	if len(p.config.Git.Branch) > 0 {
+		args = append(args, "--branch", p.config.Git.Branch)
+	}
+	args = append(args, "--", p.config.Git.URL, tmpPath)
+
+	if err := runGit(ctx, args...); err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return err
+	}
+
+	if err := os.RemoveAll(p.checkoutPath); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, p.checkoutPath)
+}
+
+// runGit runs git with specified arguments. Git never asks for
+// credentials, so it can't hang waiting for input.
+func runGit(ctx context.Context, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := runGroup(ctx, cmd); err != nil {
+		if message := tail(stderr.String(), execStderrLimit); len(message) > 0 {
+			return fmt.Errorf("%v: %s", err, message)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// metadata returns metadata of image taken from metadata.json
+// in repository root and sidecar file next to image. Fields set
+// in sidecar take precedence. Missing or malformed files are
+// skipped.
+func (p *GitProvider) metadata(imgPath string) *gitMetadata {
+	metadata := &gitMetadata{}
+
+	data, err := ioutil.ReadFile(filepath.Join(p.checkoutPath, gitMetadataFile))
+	if err == nil {
+		var all map[string]*gitMetadata
+		if err := json.Unmarshal(data, &all); err != nil {
+			log.Printf("[Parse %s] %v", gitMetadataFile, err)
+		} else if m, ok := all[p.relativePath(imgPath)]; ok && m != nil {
+			metadata = m
+		}
+	}
+
+	sidecarPath := strings.TrimSuffix(imgPath, filepath.Ext(imgPath)) + gitSidecarExt
+	data, err = ioutil.ReadFile(sidecarPath)
+	if err != nil {
+		return metadata
+	}
+
+	fields := parseFrontMatter(data)
+	if title, ok := fields["title"]; ok {
+		metadata.Title = title
+	}
+	if author, ok := fields["author"]; ok {
+		metadata.Author = author
+	}
+	if authorURL, ok := fields["author_url"]; ok {
+		metadata.AuthorURL = authorURL
+	}
+
+	return metadata
+}
+
+// parseFrontMatter parses "key: value" lines of front matter
+// enclosed in "---" lines at the beginning of document. Values
+// may be quoted.
+func parseFrontMatter(data []byte) map[string]string {
+	fields := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != frontMatterDelimiter {
+		return fields
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == frontMatterDelimiter {
+			break
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		fields[key] = value
+	}
+
+	return fields
+}
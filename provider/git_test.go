@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// git runs git in dir on behalf of test author.
+func git(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+
+	output, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
+
+// commitFile writes file to repository and commits it.
+func commitFile(t *testing.T, dir, name, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	git(t, dir, "add", "--all")
+	git(t, dir, "commit", "--quiet", "--message", "Add "+name)
+}
+
+func TestGitProvider_Provide(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blider_git_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "walls")
+	assert.NoError(t, os.MkdirAll(remote, 0755))
+	git(t, remote, "init", "--quiet")
+
+	commitFile(t, remote, "metadata.json", `{"nature/fjord.jpg": {"title": "Fjord", "author": "Erik"}}`)
+	commitFile(t, remote, "nature/fjord.md", "---\nauthor_url: \"https://example.com/erik\"\n---\nShot in Norway.\n")
+	commitFile(t, remote, "nature/fjord.jpg", "fjord")
+
+	cfg := config.NewDefault()
+	cfg.Git.URL = "file://" + remote
+	cfg.Git.CheckoutsPath = filepath.Join(dir, "checkouts")
+
+	p := &GitProvider{}
+	p.Init(cfg, rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("fjord"), wallpaper.ImgBuffer)
+	assert.Regexp(t, `-fjord\.jpg$`, wallpaper.Filename)
+	assert.Equal(t, "Fjord", wallpaper.Title)
+	assert.Equal(t, "Erik", wallpaper.Author)
+	assert.Equal(t, "https://example.com/erik", wallpaper.AuthorURL)
+	assert.Equal(t, cfg.Git.URL+"#nature/fjord.jpg", wallpaper.OriginURL)
+
+	// New image shows up only after pull interval, until
+	// then provider starts over with the only image. Images
+	// inside .git are never picked.
+	commitFile(t, remote, "glacier.png", "glacier")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(p.checkoutPath, ".git", "blob.png"), []byte("blob"), 0644))
+
+	// Each copy is stored under its own name, so history
+	// rows never share image file.
+	firstFilename := wallpaper.Filename
+	wallpaper, err = p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Fjord", wallpaper.Title)
+	assert.NotEqual(t, firstFilename, wallpaper.Filename)
+
+	p.pulledAt = time.Time{}
+	wallpaper, err = p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "glacier", wallpaper.Title)
+
+	// History rewritten on remote can't be fast-forwarded,
+	// so the last good checkout is kept.
+	git(t, remote, "reset", "--quiet", "--hard", "HEAD~2")
+	commitFile(t, remote, "desert.png", "desert")
+
+	// Glacier has been given last, so it's not repeated
+	// right away when provider starts over.
+	p.pulledAt = time.Time{}
+	wallpaper, err = p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Fjord", wallpaper.Title)
+	assert.FileExists(t, filepath.Join(p.checkoutPath, "glacier.png"))
+
+	// Unreachable remote without checkout is transient error.
+	cfg.Git.URL = "file://" + filepath.Join(dir, "missing")
+	p.Init(cfg, rep)
+
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, Transient, KindOf(err))
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	fields := parseFrontMatter([]byte("---\ntitle: 'Dunes: at dawn'\n# comment\nauthor: Ann\n---\ntitle: body\n"))
+	assert.Equal(t, map[string]string{"title": "Dunes: at dawn", "author": "Ann"}, fields)
+
+	assert.Empty(t, parseFrontMatter([]byte("title: no front matter\n")))
+}
@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"time"
+)
+
+// healthTracker counts consecutive failures of providers and
+// marks provider failed too many times unhealthy for a cool-down.
+// State is persisted in repository, so it survives restarts.
+type healthTracker struct {
+	repository *repository.Repository
+	threshold  int
+	coolDown   time.Duration
+	health     map[string]*repository.ProviderHealth
+}
+
+func newHealthTracker(
+	rep *repository.Repository,
+	threshold int,
+	coolDown time.Duration,
+) *healthTracker {
+	t := &healthTracker{
+		repository: rep,
+		threshold:  threshold,
+		coolDown:   coolDown,
+		health:     make(map[string]*repository.ProviderHealth),
+	}
+
+	if rep == nil {
+		return t
+	}
+
+	health, err := rep.GetProvidersHealth()
+	if err != nil {
+		log.Printf("[Load providers health] %v", err)
+		return t
+	}
+	t.health = health
+
+	for name, h := range health {
+		if t.isUnhealthy(name, time.Now()) {
+			log.Printf(
+				"Provider '%s' is unhealthy until %s",
+				name,
+				time.Unix(int64(h.UnhealthyUntil), 0).Format(time.RFC3339),
+			)
+		}
+	}
+
+	return t
+}
+
+// isUnhealthy reports whether provider is in cool-down at moment now.
+func (t *healthTracker) isUnhealthy(name string, now time.Time) bool {
+	h, ok := t.health[name]
+	return ok && now.Unix() < int64(h.UnhealthyUntil)
+}
+
+// recordSuccess resets failures counter of provider.
+func (t *healthTracker) recordSuccess(name string) {
+	h, ok := t.health[name]
+	if !ok || h.Failures == 0 {
+		return
+	}
+
+	if h.Failures >= t.threshold {
+		log.Printf("Provider '%s' has recovered", name)
+	}
+
+	h.Failures = 0
+	h.UnhealthyUntil = 0
+	t.save(h)
+}
+
+// recordFailure counts failure of provider and starts cool-down
+// once provider reaches failure threshold. Provider failed right
+// after cool-down goes back to cool-down immediately.
+func (t *healthTracker) recordFailure(name string, now time.Time) {
+	h, ok := t.health[name]
+	if !ok {
+		h = &repository.ProviderHealth{Name: name}
+		t.health[name] = h
+	}
+
+	h.Failures++
+
+	if h.Failures >= t.threshold {
+		until := now.Add(t.coolDown)
+		h.UnhealthyUntil = uint(until.Unix())
+		log.Printf(
+			"Provider '%s' failed %d times in a row and is unhealthy until %s",
+			name,
+			h.Failures,
+			until.Format(time.RFC3339),
+		)
+	} else {
+		log.Printf("Provider '%s' failed (%d/%d)", name, h.Failures, t.threshold)
+	}
+
+	t.save(h)
+}
+
+func (t *healthTracker) save(h *repository.ProviderHealth) {
+	if t.repository == nil {
+		return
+	}
+
+	if err := t.repository.SaveProviderHealth(h); err != nil {
+		log.Printf("[Save health of provider '%s'] %v", h.Name, err)
+	}
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"context"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalDirectoryProvider is provider of images picked from
+// user's folders. Picked images are used in place and are
+// never copied to or removed by local image repository.
+type LocalDirectoryProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+}
+
+func (p *LocalDirectoryProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing LocalDirectoryProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "local", config)
+}
+
+// Provide picks random image not seen yet from configured
+// folders. Once all images have been seen, it starts over.
+func (p *LocalDirectoryProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	options := p.config.Local
+	log.Printf("Looking for images in %s...", strings.Join(options.Paths, ", "))
+
+	images, err := findImages(ctx, options.Paths, options.Extensions, options.Patterns)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, transient("Provide", err)
+		}
+		return nil, permanent("Provide", err)
+	}
+
+	if len(images) == 0 {
+		return nil, noMoreContent("Provide", "no images found in configured folders")
+	}
+
+	imgPath, err := p.seen.pickUnseenFile(images, fileURL)
+	if err != nil {
+		return nil, wrap("Provide", err)
+	}
+
+	log.Printf("Picked %s", imgPath)
+
+	filename := filepath.Base(imgPath)
+	originURL := fileURL(imgPath)
+	p.seen.markSeen(originURL)
+
+	return &repository.Wallpaper{
+		OriginURL:      originURL,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          strings.TrimSuffix(filename, filepath.Ext(filename)),
+		LocalPath:      imgPath,
+	}, nil
+}
+
+// fileURL returns file:// URL of image at imgPath.
+func fileURL(imgPath string) string {
+	return (&url.URL{Scheme: "file", Path: imgPath}).String()
+}
+
+// findImages recursively walks through dirs and returns absolute
+// paths of files having one of extensions and matching at least
+// one of glob patterns. Missing or unreadable folders and hidden
+// folders inside dirs are skipped.
+// Walking stops as soon as ctx is done.
+func findImages(ctx context.Context, dirs, extensions, patterns []string) ([]string, error) {
+	allowedExtensions := make(map[string]bool)
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		allowedExtensions[ext] = true
+	}
+
+	var images []string
+
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		walkErr := filepath.Walk(dir, func(imgPath string, info os.FileInfo, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if err != nil {
+				log.Printf("[Walk %s] %v", imgPath, err)
+				if info != nil && info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// Hidden folders (e.g. .git of repository) hold
+			// no wallpapers but may be large.
+			if info.IsDir() && imgPath != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			if info.IsDir() || !info.Mode().IsRegular() {
+				return nil
+			}
+
+			ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(imgPath), "."))
+			if !allowedExtensions[ext] {
+				return nil
+			}
+
+			matched, err := matchesAny(patterns, info.Name())
+			if err != nil {
+				return err
+			}
+
+			if matched {
+				images = append(images, imgPath)
+			}
+
+			return nil
+		})
+		if walkErr != nil {
+			return nil, walkErr
+		}
+	}
+
+	return images, nil
+}
+
+// matchesAny reports whether name matches at least one of glob
+// patterns. Empty list of patterns matches any name.
+func matchesAny(patterns []string, name string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+
+	for _, pattern := range patterns {
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalDirectoryProvider_Provide(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blider_local_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	nested := filepath.Join(dir, "nature", "forest")
+	assert.NoError(t, os.MkdirAll(nested, os.ModePerm))
+
+	wanted := filepath.Join(nested, "pines_4k.JPG")
+	for _, name := range []string{
+		wanted,
+		filepath.Join(dir, "notes_4k.txt"),
+		filepath.Join(dir, "city_hd.png"),
+	} {
+		assert.NoError(t, ioutil.WriteFile(name, []byte{}, os.ModePerm))
+	}
+
+	cfg := config.NewDefault()
+	cfg.Local.Paths = []string{dir, filepath.Join(dir, "missing")}
+	cfg.Local.Patterns = []string{"*_4k.*"}
+
+	p := &LocalDirectoryProvider{}
+	p.Init(cfg, rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, wanted, wallpaper.LocalPath)
+	assert.Equal(t, "pines_4k.JPG", wallpaper.Filename)
+	assert.Equal(t, "pines_4k", wallpaper.Title)
+	assert.Equal(t, "file://"+wanted, wallpaper.OriginURL)
+
+	// The only matching image is given again.
+	wallpaper, err = p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, wanted, wallpaper.LocalPath)
+
+	cfg.Local.Patterns = []string{"*_8k.*"}
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+}
+
+func TestLocalDirectoryProvider_ProvideStartsOver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blider_local_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte{}, os.ModePerm))
+	}
+
+	cfg := config.NewDefault()
+	cfg.Local.Paths = []string{dir}
+
+	p := &LocalDirectoryProvider{}
+	p.Init(cfg, rep)
+
+	given := make(map[string]bool)
+	last := ""
+	for i := 0; i < 3; i++ {
+		wallpaper, err := p.Provide(context.Background())
+		assert.NoError(t, err)
+		given[wallpaper.Filename] = true
+		last = wallpaper.Filename
+	}
+	assert.Len(t, given, 3)
+
+	// Folder is exhausted, so provider starts over without
+	// repeating the last image right away.
+	given = make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		wallpaper, err := p.Provide(context.Background())
+		assert.NoError(t, err)
+		if i == 0 {
+			assert.NotEqual(t, last, wallpaper.Filename)
+		}
+		given[wallpaper.Filename] = true
+	}
+	assert.Len(t, given, 3)
+}
@@ -0,0 +1,239 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDiscoveredPage limits binary search of the last page,
+// so site returning items for any page number can't make
+// discovery endless.
+const maxDiscoveredPage = 1 << 16
+
+// listPageURL returns URL of list page having specified number.
+func listPageURL(listURL string, page int) string {
+	return strings.Replace(listURL, pagePlaceholder, strconv.Itoa(page), -1)
+}
+
+// loadPageCount creates site with page count stored in repository.
+// Sites with configured last page don't need discovery. Until page
+// count is discovered, site is assumed to have MaxFetchPages pages.
+func (p *ScraperProvider) loadPageCount(site config.ScraperSite) *scraperSite {
+	s := &scraperSite{
+		ScraperSite: site,
+		lastPage:    site.LastPage,
+	}
+
+	if s.LastPage > 0 {
+		if s.lastPage < s.FirstPage {
+			s.lastPage = s.FirstPage
+		}
+		return s
+	}
+
+	s.lastPage = s.FirstPage + p.config.MaxFetchPages - 1
+
+	if p.repository == nil {
+		return s
+	}
+
+	count, err := p.repository.GetPageCount(site.ListURL)
+	if err != nil {
+		log.Printf("[Load page count of %s] %v", site.Name, err)
+		return s
+	}
+
+	if count != nil && count.LastPage >= s.FirstPage {
+		s.lastPage = count.LastPage
+		s.discoveredAt = time.Unix(int64(count.UpdatedAt), 0)
+	}
+
+	return s
+}
+
+// refreshPageCount discovers page count of site if it has never
+// been discovered or was discovered longer than refresh period ago.
+// If discovery fails, page count known so far is used.
+func (p *ScraperProvider) refreshPageCount(ctx context.Context, s *scraperSite) {
+	if s.LastPage > 0 || time.Since(s.discoveredAt) < p.pageCountRefresh {
+		return
+	}
+
+	log.Printf("Discovering number of pages of %s...", s.Name)
+
+	lastPage, err := discoverLastPage(ctx, p.client, &s.ScraperSite, s.lastPage)
+	if err != nil {
+		log.Printf("[Discover pages of %s] %v", s.Name, err)
+		return
+	}
+
+	s.lastPage = lastPage
+	s.discoveredAt = time.Now()
+	log.Printf("The last page of %s is %d", s.Name, lastPage)
+
+	if p.repository == nil {
+		return
+	}
+
+	err = p.repository.SavePageCount(&repository.PageCount{
+		ListURL:   s.ListURL,
+		LastPage:  lastPage,
+		UpdatedAt: uint(s.discoveredAt.Unix()),
+	})
+	if err != nil {
+		log.Printf("[Save page count of %s] %v", s.Name, err)
+	}
+}
+
+// discoverLastPage finds number of the last list page. Pagination
+// links are looked at first. If site has no pagination selector or
+// links have no page numbers, the last page is found by binary
+// search starting from guess.
+func discoverLastPage(
+	ctx context.Context,
+	client *httpclient.Client,
+	site *config.ScraperSite,
+	guess int,
+) (int, error) {
+	if len(site.PaginationSelector) > 0 {
+		lastPage, err := lastPageFromPagination(ctx, client, site)
+		if err != nil {
+			return 0, err
+		}
+
+		if lastPage >= site.FirstPage {
+			return lastPage, nil
+		}
+	}
+
+	return searchLastPage(ctx, client, site, guess)
+}
+
+// lastPageFromPagination returns the largest page number found
+// in texts and URLs of pagination links on the first list page.
+// Returns zero if links have no page numbers.
+func lastPageFromPagination(
+	ctx context.Context,
+	client *httpclient.Client,
+	site *config.ScraperSite,
+) (int, error) {
+	firstURL := listPageURL(site.ListURL, site.FirstPage)
+
+	doc, err := getDocument(ctx, client, firstURL)
+	if err != nil {
+		return 0, err
+	}
+
+	placeholder := strings.Index(site.ListURL, pagePlaceholder)
+	prefix := site.ListURL[:placeholder]
+	suffix := site.ListURL[placeholder+len(pagePlaceholder):]
+
+	lastPage := 0
+	consider := func(value string) {
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n > lastPage {
+			lastPage = n
+		}
+	}
+
+	doc.Find(site.PaginationSelector).Each(func(_ int, link *goquery.Selection) {
+		consider(link.Text())
+
+		href, ok := link.Attr("href")
+		if !ok {
+			return
+		}
+
+		href = resolveURL(firstURL, href)
+		if strings.HasPrefix(href, prefix) && strings.HasSuffix(href, suffix) &&
+			len(href) > len(prefix)+len(suffix) {
+			consider(href[len(prefix) : len(href)-len(suffix)])
+		}
+	})
+
+	return lastPage, nil
+}
+
+// searchLastPage finds the last list page having items. Upper
+// bound starts from guess and is doubled until page without items
+// is found, then the last page is found by binary search.
+func searchLastPage(
+	ctx context.Context,
+	client *httpclient.Client,
+	site *config.ScraperSite,
+	guess int,
+) (int, error) {
+	lo := site.FirstPage
+	exists, err := pageHasItems(ctx, client, site, lo)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, errors.New("the first list page has no items")
+	}
+
+	hi := guess
+	if hi <= lo {
+		hi = lo + 1
+	}
+
+	// Page lo has items and page hi is looked for that has none.
+	for {
+		exists, err := pageHasItems(ctx, client, site, hi)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			break
+		}
+
+		lo = hi
+		if hi >= maxDiscoveredPage {
+			return hi, nil
+		}
+		hi *= 2
+	}
+
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+
+		exists, err := pageHasItems(ctx, client, site, mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if exists {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo, nil
+}
+
+// pageHasItems reports whether list page exists and has items.
+// Page responding with permanent error (e.g. 404) doesn't exist.
+func pageHasItems(
+	ctx context.Context,
+	client *httpclient.Client,
+	site *config.ScraperSite,
+	page int,
+) (bool, error) {
+	doc, err := getDocument(ctx, client, listPageURL(site.ListURL, page))
+	if err != nil {
+		if ctx.Err() == nil && KindOf(err) == Permanent {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return doc.Find(site.ItemSelector).Length() > 0, nil
+}
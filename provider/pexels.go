@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pexelsAPIURL = "https://api.pexels.com/v1"
+	// pexelsPageSize is maximum number of photos per page
+	// allowed by Pexels API.
+	pexelsPageSize = 80
+	// ratioTolerance is relative difference between aspect
+	// ratios still considered equal.
+	ratioTolerance = 0.02
+)
+
+// PexelsProvider is provider of photos taken from
+// https://www.pexels.com via Pexels API.
+type PexelsProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+	apiURL     string
+	// pageCount is number of result pages known from
+	// the last response.
+	pageCount int
+}
+
+type pexelsPage struct {
+	TotalResults int            `json:"total_results"`
+	Photos       []*pexelsPhoto `json:"photos"`
+}
+
+type pexelsPhoto struct {
+	ID              int    `json:"id"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	URL             string `json:"url"`
+	Alt             string `json:"alt"`
+	Photographer    string `json:"photographer"`
+	PhotographerURL string `json:"photographer_url"`
+	Src             struct {
+		Original string `json:"original"`
+	} `json:"src"`
+}
+
+func (p *PexelsProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing PexelsProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "pexels", config)
+	p.client = newHTTPClient(config)
+	p.pageCount = 1
+
+	if len(p.apiURL) == 0 {
+		p.apiURL = pexelsAPIURL
+	}
+}
+
+// Provide requests random page of photos matching configured
+// filters from Pexels API and downloads random photo not seen yet.
+func (p *PexelsProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", p.apiURL)
+
+	options := p.config.Pexels
+
+	apiKey, err := p.config.Secret("pexels_api_key")
+	if err != nil {
+		return nil, permanent("Read Pexels API key", err)
+	}
+	if len(apiKey) == 0 {
+		return nil, permanent("Provide", errors.New("Pexels API key is not configured"))
+	}
+
+	ratios, err := parseRatios(options.Ratios)
+	if err != nil {
+		return nil, permanent("Provide", err)
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	page := 1 + rand.Intn(p.pageCount)
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(pexelsPageSize))
+
+	endpoint := "curated"
+	if len(options.Query) > 0 {
+		endpoint = "search"
+		query.Set("query", options.Query)
+
+		if len(options.Color) > 0 {
+			query.Set("color", options.Color)
+		}
+		if orientation := pexelsOrientation(ratios); len(orientation) > 0 {
+			query.Set("orientation", orientation)
+		}
+		if size := pexelsSize(options.MinWidth, options.MinHeight); len(size) > 0 {
+			query.Set("size", size)
+		}
+	} else if len(options.Color) > 0 {
+		return nil, permanent("Provide", errors.New("Pexels color filter requires query"))
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", apiKey)
+
+	pageURL := fmt.Sprintf("%s/%s?%s", p.apiURL, endpoint, query.Encode())
+
+	var result pexelsPage
+	if err := getJSON(ctx, p.client, pageURL, header, &result); err != nil {
+		return nil, wrap(fmt.Sprintf("Provide %s", pageURL), err)
+	}
+
+	p.pageCount = (result.TotalResults + pexelsPageSize - 1) / pexelsPageSize
+	if p.pageCount < 1 {
+		p.pageCount = 1
+	}
+
+	var candidates []*pexelsPhoto
+	for _, photo := range result.Photos {
+		if photo.Width < options.MinWidth || photo.Height < options.MinHeight ||
+			!matchesRatio(ratios, photo.Width, photo.Height) {
+			continue
+		}
+
+		seen, err := p.seen.isSeen(photo.URL)
+		if err != nil {
+			return nil, transient(fmt.Sprintf("Check whether photo %d is seen", photo.ID), err)
+		}
+
+		if !seen {
+			candidates = append(candidates, photo)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, noMoreContent("Provide", "no new photos matching filters on page %d", page)
+	}
+
+	photo := candidates[rand.Intn(len(candidates))]
+
+	filename, img, err := downloadImageToBuffer(ctx, p.client, photo.Src.Original)
+	if err != nil {
+		return nil, wrap(fmt.Sprintf("Provide photo %d", photo.ID), err)
+	}
+
+	p.seen.markSeen(photo.URL)
+
+	return &repository.Wallpaper{
+		OriginURL:      photo.URL,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          strings.TrimSpace(photo.Alt),
+		Author:         photo.Photographer,
+		AuthorURL:      photo.PhotographerURL,
+		ImgBuffer:      img,
+	}, nil
+}
+
+// pexelsOrientation returns orientation all ratios share or
+// empty string if they have different orientations.
+func pexelsOrientation(ratios []float64) string {
+	orientation := ""
+
+	for _, ratio := range ratios {
+		current := "square"
+		if ratio > 1+ratioTolerance {
+			current = "landscape"
+		} else if ratio < 1-ratioTolerance {
+			current = "portrait"
+		}
+
+		if len(orientation) > 0 && orientation != current {
+			return ""
+		}
+		orientation = current
+	}
+
+	return orientation
+}
+
+// pexelsSize returns the largest Pexels size filter (large is
+// 24MP, medium is 12MP, small is 4MP) photos of specified
+// minimum resolution fit into.
+func pexelsSize(minWidth, minHeight int) string {
+	pixels := minWidth * minHeight
+
+	switch {
+	case pixels >= 24000000:
+		return "large"
+	case pixels >= 12000000:
+		return "medium"
+	case pixels >= 4000000:
+		return "small"
+	default:
+		return ""
+	}
+}
+
+// parseRatios parses aspect ratios in "<width>x<height>" format.
+func parseRatios(values []string) ([]float64, error) {
+	var ratios []float64
+
+	for _, value := range values {
+		parts := strings.Split(strings.TrimSpace(value), "x")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed aspect ratio '%s'", value)
+		}
+
+		width, err := strconv.Atoi(parts[0])
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("malformed aspect ratio '%s'", value)
+		}
+
+		height, err := strconv.Atoi(parts[1])
+		if err != nil || height <= 0 {
+			return nil, fmt.Errorf("malformed aspect ratio '%s'", value)
+		}
+
+		ratios = append(ratios, float64(width)/float64(height))
+	}
+
+	return ratios, nil
+}
+
+// matchesRatio reports whether image of specified size has
+// one of ratios. Empty list of ratios matches any image.
+func matchesRatio(ratios []float64, width, height int) bool {
+	if len(ratios) == 0 {
+		return true
+	}
+
+	if width <= 0 || height <= 0 {
+		return false
+	}
+
+	actual := float64(width) / float64(height)
+	for _, ratio := range ratios {
+		if math.Abs(actual-ratio)/ratio <= ratioTolerance {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestPexelsProvider_Provide(t *testing.T) {
+	var (
+		query      url.Values
+		authHeader string
+	)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		authHeader = r.Header.Get("Authorization")
+		_, _ = fmt.Fprintf(w, `{
+			"total_results": 1,
+			"photos": [{
+				"id": 1,
+				"width": 1000,
+				"height": 1000,
+				"url": "https://www.pexels.com/photo/1/",
+				"src": {"original": "%[1]s/photos/1.jpeg"}
+			}, {
+				"id": 2,
+				"width": 3840,
+				"height": 2160,
+				"url": "https://www.pexels.com/photo/2/",
+				"alt": "Misty lake",
+				"photographer": "John Smith",
+				"photographer_url": "https://www.pexels.com/@john",
+				"src": {"original": "%[1]s/photos/2.jpeg"}
+			}]
+		}`, server.URL)
+	})
+	mux.HandleFunc("/photos/2.jpeg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("lake"))
+	})
+
+	_ = os.Setenv("BLIDER_PEXELS_API_KEY", "secret")
+	defer os.Unsetenv("BLIDER_PEXELS_API_KEY")
+
+	cfg := config.NewDefault()
+	cfg.Pexels.Query = "lake"
+	cfg.Pexels.Color = "blue"
+	cfg.Pexels.MinWidth = 1920
+	cfg.Pexels.Ratios = []string{"16x9", "16x10"}
+
+	p := &PexelsProvider{apiURL: server.URL}
+	p.Init(cfg, rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("lake"), wallpaper.ImgBuffer)
+	assert.Regexp(t, `-2\.jpeg$`, wallpaper.Filename)
+	assert.Equal(t, "Misty lake", wallpaper.Title)
+	assert.Equal(t, "John Smith", wallpaper.Author)
+	assert.Equal(t, "https://www.pexels.com/@john", wallpaper.AuthorURL)
+	assert.Equal(t, "https://www.pexels.com/photo/2/", wallpaper.OriginURL)
+
+	assert.Equal(t, "secret", authHeader)
+	assert.Equal(t, "lake", query.Get("query"))
+	assert.Equal(t, "blue", query.Get("color"))
+	assert.Equal(t, "landscape", query.Get("orientation"))
+
+	// The only photo matching filters has already been given.
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+}
+
+func TestPexelsProvider_ProvideWithoutAPIKey(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.SecretsPath = "missing_secrets.json"
+
+	p := &PexelsProvider{apiURL: "http://127.0.0.1:0"}
+	p.Init(cfg, nil)
+
+	_, err := p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+}
+
+func TestMatchesRatio(t *testing.T) {
+	ratios, err := parseRatios([]string{"16x9", "21x9"})
+	assert.NoError(t, err)
+
+	assert.True(t, matchesRatio(ratios, 1920, 1080))
+	assert.True(t, matchesRatio(ratios, 2560, 1080))
+	assert.False(t, matchesRatio(ratios, 1920, 1200))
+	assert.True(t, matchesRatio(nil, 1920, 1200))
+
+	_, err = parseRatios([]string{"wide"})
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"context"
 	"github.com/ildarkarymoff/blider/config"
 	"github.com/ildarkarymoff/blider/repository"
 )
@@ -14,9 +15,8 @@ type IProvider interface {
 	Init(config *config.Config, storage *repository.Repository)
 
 	// Provide is a main method for each provider that
-	// must obtain or generate image. Also at current
-	// code state it save wallpaper in database, but
-	// saving to database logic will be moved to separate
-	// module in future versions.
-	Provide() *repository.Wallpaper
+	// must obtain or generate image. Returned error is
+	// *Error telling whether it's worth trying again.
+	// Provider must stop as soon as ctx is done.
+	Provide(ctx context.Context) (*repository.Wallpaper, error)
 }
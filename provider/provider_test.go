@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"fmt"
+	"github.com/ildarkarymoff/blider/repository"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	rep *repository.Repository
+)
+
+func TestMain(m *testing.M) {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("Failed to get working directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbPath := filepath.Join(wd, "blider_test.sqlite")
+
+	// Default configuration keeps HTTP cache in home directory.
+	homePath := filepath.Join(wd, ".blider_test_home")
+	_ = os.Setenv("HOME", homePath)
+
+	rep, err = repository.Open(dbPath)
+	if err != nil {
+		fmt.Printf("Failed to open repository: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	_ = rep.Close()
+	_ = os.Remove(dbPath)
+	_ = os.RemoveAll(homePath)
+
+	os.Exit(code)
+}
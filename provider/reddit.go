@@ -0,0 +1,306 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"math/rand"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redditURL = "https://www.reddit.com"
+	// redditListingLimit is maximum number of posts Reddit
+	// returns per listing request.
+	redditListingLimit = 100
+	// redditMaxDownloads is maximum number of candidates tried
+	// to be downloaded per one change.
+	redditMaxDownloads = 5
+)
+
+// redditResolutionRe extracts image resolution from post
+// titles like "Mountain lake [3840x2160]".
+var redditResolutionRe = regexp.MustCompile(`(\d{3,5})\s*[xX×*]\s*(\d{3,5})`)
+
+// RedditProvider is provider of images posted to
+// subreddits like r/wallpapers or r/EarthPorn.
+type RedditProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+	baseURL    string
+}
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data *redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditPost struct {
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Permalink   string `json:"permalink"`
+	URL         string `json:"url"`
+	Over18      bool   `json:"over_18"`
+	Score       int    `json:"score"`
+	IsGallery   bool   `json:"is_gallery"`
+	GalleryData struct {
+		Items []struct {
+			MediaID string `json:"media_id"`
+		} `json:"items"`
+	} `json:"gallery_data"`
+	MediaMetadata map[string]struct {
+		Kind   string `json:"e"`
+		Source struct {
+			URL    string `json:"u"`
+			Width  int    `json:"x"`
+			Height int    `json:"y"`
+		} `json:"s"`
+	} `json:"media_metadata"`
+}
+
+// redditImage is direct link to image found in post.
+type redditImage struct {
+	post   *redditPost
+	url    string
+	width  int
+	height int
+}
+
+func (p *RedditProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing RedditProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "reddit", config)
+	p.client = newHTTPClient(config)
+
+	if len(p.baseURL) == 0 {
+		p.baseURL = redditURL
+	}
+}
+
+// Provide reads listings of configured subreddits and downloads
+// random image from posts passing configured filters.
+func (p *RedditProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", p.baseURL)
+
+	var candidates []*redditImage
+	var lastErr error
+
+	for _, subreddit := range p.config.Reddit.Subreddits {
+		posts, err := p.fetchListing(ctx, subreddit)
+		if err != nil {
+			lastErr = wrap(fmt.Sprintf("Provide r/%s", subreddit), err)
+			if ctx.Err() != nil {
+				return nil, lastErr
+			}
+			log.Println(lastErr)
+			continue
+		}
+
+		for _, post := range posts {
+			if !p.accepts(post) {
+				continue
+			}
+
+			seen, err := p.seen.isSeen(p.permalink(post))
+			if err != nil {
+				return nil, transient(fmt.Sprintf("Check whether %s is seen", post.Permalink), err)
+			}
+
+			if seen {
+				continue
+			}
+
+			for _, image := range redditImages(post) {
+				if p.fits(image) {
+					candidates = append(candidates, image)
+				}
+			}
+		}
+	}
+
+	// Failed listings may have suitable posts, so it's worth
+	// trying again only if some of listings failed.
+	if len(candidates) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, noMoreContent("Provide", "no suitable posts found")
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	for i := 0; i < len(candidates) && i < redditMaxDownloads; i++ {
+		image := candidates[i]
+
+		filename, img, err := downloadImageToBuffer(ctx, p.client, image.url)
+		if err != nil {
+			lastErr = wrap(fmt.Sprintf("Provide image %s", image.url), err)
+			if ctx.Err() != nil {
+				return nil, lastErr
+			}
+			log.Println(lastErr)
+			continue
+		}
+
+		p.seen.markSeen(p.permalink(image.post))
+
+		return &repository.Wallpaper{
+			OriginURL:      p.permalink(image.post),
+			Filename:       filename,
+			FetchTimestamp: uint(time.Now().Unix()),
+			Title:          image.post.Title,
+			Author:         image.post.Author,
+			AuthorURL:      fmt.Sprintf("%s/user/%s", redditURL, image.post.Author),
+			ImgBuffer:      img,
+		}, nil
+	}
+
+	return nil, lastErr
+}
+
+func (p *RedditProvider) fetchListing(ctx context.Context, subreddit string) ([]*redditPost, error) {
+	query := url.Values{}
+	query.Set("t", p.config.Reddit.Time)
+	query.Set("limit", strconv.Itoa(redditListingLimit))
+	query.Set("raw_json", "1")
+
+	listingURL := fmt.Sprintf(
+		"%s/r/%s/%s.json?%s",
+		p.baseURL,
+		url.PathEscape(strings.TrimPrefix(subreddit, "r/")),
+		p.config.Reddit.Sort,
+		query.Encode(),
+	)
+	log.Printf("Fetching %s...", listingURL)
+
+	var listing redditListing
+	if err := getJSON(ctx, p.client, listingURL, nil, &listing); err != nil {
+		return nil, err
+	}
+
+	var posts []*redditPost
+	for _, child := range listing.Data.Children {
+		if child.Data != nil {
+			posts = append(posts, child.Data)
+		}
+	}
+
+	return posts, nil
+}
+
+// accepts reports whether post passes NSFW and score filters.
+func (p *RedditProvider) accepts(post *redditPost) bool {
+	return !post.Over18 && post.Score >= p.config.Reddit.MinScore
+}
+
+// fits reports whether image passes resolution filters. Images
+// of unknown resolution fit only if no minimum is configured.
+func (p *RedditProvider) fits(image *redditImage) bool {
+	options := p.config.Reddit
+	if options.MinWidth <= 0 && options.MinHeight <= 0 {
+		return true
+	}
+
+	return image.width >= options.MinWidth && image.height >= options.MinHeight
+}
+
+func (p *RedditProvider) permalink(post *redditPost) string {
+	return fmt.Sprintf("%s%s", redditURL, post.Permalink)
+}
+
+// redditImages returns direct links to images of post. Gallery posts
+// may contain several images, link posts contain at most one.
+func redditImages(post *redditPost) []*redditImage {
+	width, height := parseResolution(post.Title)
+
+	if post.IsGallery {
+		var images []*redditImage
+		for _, item := range post.GalleryData.Items {
+			media, ok := post.MediaMetadata[item.MediaID]
+			if !ok || media.Kind != "Image" || len(media.Source.URL) == 0 {
+				continue
+			}
+
+			images = append(images, &redditImage{
+				post:   post,
+				url:    media.Source.URL,
+				width:  media.Source.Width,
+				height: media.Source.Height,
+			})
+		}
+
+		return images
+	}
+
+	imgURL := directImageURL(post.URL)
+	if len(imgURL) == 0 {
+		return nil
+	}
+
+	return []*redditImage{{
+		post:   post,
+		url:    imgURL,
+		width:  width,
+		height: height,
+	}}
+}
+
+// directImageURL turns post link into direct link to image.
+// Returns empty string for links to albums, videos and other
+// pages blider can't take image from.
+func directImageURL(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	ext := strings.ToLower(path.Ext(u.Path))
+	isImage := ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".webp"
+
+	switch strings.TrimPrefix(u.Host, "www.") {
+	case "i.redd.it", "i.imgur.com":
+		if isImage {
+			return link
+		}
+	case "imgur.com", "m.imgur.com":
+		// Single image pages like https://imgur.com/abc123 are
+		// served as https://i.imgur.com/abc123.jpg. Albums and
+		// galleries are skipped.
+		id := strings.Trim(u.Path, "/")
+		if len(id) > 0 && !strings.Contains(id, "/") {
+			return fmt.Sprintf("https://i.imgur.com/%s.jpg", strings.TrimSuffix(id, path.Ext(id)))
+		}
+	}
+
+	return ""
+}
+
+// parseResolution extracts resolution from post title.
+// Returns zeros if title has no resolution.
+func parseResolution(title string) (int, int) {
+	match := redditResolutionRe.FindStringSubmatch(title)
+	if match == nil {
+		return 0, 0
+	}
+
+	width, _ := strconv.Atoi(match[1])
+	height, _ := strconv.Atoi(match[2])
+	return width, height
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedditProvider_Provide(t *testing.T) {
+	assert.NoError(t, rep.ClearHistory())
+
+	var sort, window string
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/r/EarthPorn/top.json", func(w http.ResponseWriter, r *http.Request) {
+		window = r.URL.Query().Get("t")
+		sort = "top"
+		_, _ = fmt.Fprintf(w, `{"data": {"children": [
+			{"data": {
+				"title": "Too small [1280x720]",
+				"author": "small",
+				"permalink": "/r/EarthPorn/comments/1/small/",
+				"url": "https://i.redd.it/small.jpg",
+				"score": 500
+			}},
+			{"data": {
+				"title": "Not safe [3840x2160]",
+				"author": "nsfw",
+				"permalink": "/r/EarthPorn/comments/2/nsfw/",
+				"url": "https://i.redd.it/nsfw.jpg",
+				"over_18": true,
+				"score": 500
+			}},
+			{"data": {
+				"title": "Unpopular [3840x2160]",
+				"author": "unpopular",
+				"permalink": "/r/EarthPorn/comments/3/unpopular/",
+				"url": "https://i.redd.it/unpopular.jpg",
+				"score": 5
+			}},
+			{"data": {
+				"title": "Iceland trip",
+				"author": "traveller",
+				"permalink": "/r/EarthPorn/comments/4/iceland/",
+				"url": "https://www.reddit.com/gallery/4",
+				"score": 500,
+				"is_gallery": true,
+				"gallery_data": {"items": [{"media_id": "a"}, {"media_id": "b"}]},
+				"media_metadata": {
+					"a": {"e": "Image", "s": {"u": "%[1]s/media/a.jpg", "x": 4000, "y": 3000}},
+					"b": {"e": "Image", "s": {"u": "%[1]s/media/b.jpg", "x": 800, "y": 600}}
+				}
+			}}
+		]}}`, server.URL)
+	})
+	mux.HandleFunc("/media/a.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("iceland"))
+	})
+
+	cfg := config.NewDefault()
+	cfg.Reddit.Subreddits = []string{"EarthPorn", "missing"}
+	cfg.Reddit.MinScore = 100
+	cfg.Reddit.MinWidth = 1920
+	cfg.Reddit.MinHeight = 1080
+
+	p := &RedditProvider{baseURL: server.URL}
+	p.Init(cfg, rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("iceland"), wallpaper.ImgBuffer)
+	assert.Equal(t, "Iceland trip", wallpaper.Title)
+	assert.Equal(t, "traveller", wallpaper.Author)
+	assert.Equal(t, "https://www.reddit.com/user/traveller", wallpaper.AuthorURL)
+	assert.Equal(t, "https://www.reddit.com/r/EarthPorn/comments/4/iceland/", wallpaper.OriginURL)
+	assert.Equal(t, "top", sort)
+	assert.Equal(t, "week", window)
+}
+
+func TestDirectImageURL(t *testing.T) {
+	assert.Equal(t, "https://i.redd.it/abc.jpg", directImageURL("https://i.redd.it/abc.jpg"))
+	assert.Equal(t, "https://i.imgur.com/abc.png", directImageURL("https://i.imgur.com/abc.png"))
+	assert.Equal(t, "https://i.imgur.com/abc.jpg", directImageURL("https://imgur.com/abc"))
+	assert.Equal(t, "", directImageURL("https://imgur.com/a/abc"))
+	assert.Equal(t, "", directImageURL("https://i.imgur.com/abc.gifv"))
+	assert.Equal(t, "", directImageURL("https://v.redd.it/abc"))
+	assert.Equal(t, "", directImageURL("https://example.org/abc.jpg"))
+}
+
+func TestParseResolution(t *testing.T) {
+	width, height := parseResolution("Lake Louise, Canada [OC] [3840x2160]")
+	assert.Equal(t, 3840, width)
+	assert.Equal(t, 2160, height)
+
+	width, height = parseResolution("Dunes (5000 × 3333)")
+	assert.Equal(t, 5000, width)
+	assert.Equal(t, 3333, height)
+
+	width, height = parseResolution("No resolution here")
+	assert.Equal(t, 0, width)
+	assert.Equal(t, 0, height)
+}
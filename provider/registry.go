@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/ildarkarymoff/blider/rules"
+	"log"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// factory creates provider and knows which configuration
+// section provider options are merged into.
+type factory struct {
+	// section is JSON key of provider's configuration section.
+	// Empty section means configuration root.
+	section string
+	create  func() IProvider
+}
+
+// factories maps provider names used in configuration to factories.
+var factories = map[string]*factory{
+	"simpledesktops": {"", func() IProvider { return &SimpleDesktopsProvider{} }},
+	"unsplash":       {"unsplash", func() IProvider { return &UnsplashProvider{} }},
+	"pexels":         {"pexels", func() IProvider { return &PexelsProvider{} }},
+	"wallhaven":      {"wallhaven", func() IProvider { return &WallhavenProvider{} }},
+	"bing":           {"bing", func() IProvider { return &BingProvider{} }},
+	"apod":           {"apod", func() IProvider { return &ApodProvider{} }},
+	"wikimedia":      {"wikimedia", func() IProvider { return &WikimediaProvider{} }},
+	"local":          {"local", func() IProvider { return &LocalDirectoryProvider{} }},
+	"git":            {"git", func() IProvider { return &GitProvider{} }},
+	"reddit":         {"reddit", func() IProvider { return &RedditProvider{} }},
+	"feed":           {"feed", func() IProvider { return &FeedProvider{} }},
+	"scraper":        {"scraper", func() IProvider { return &ScraperProvider{} }},
+	"generator":      {"generator", func() IProvider { return &GeneratorProvider{} }},
+	"exec":           {"exec", func() IProvider { return &ExecProvider{} }},
+}
+
+// Registry is provider combining providers listed in configuration.
+// For each change it chooses one of them randomly according to their
+// weights. If chosen provider fails, the rest are tried in order they
+// are listed. Providers failing too often are skipped for a cool-down.
+// Before provider is asked for wallpaper, options of matching rules
+// are merged into its configuration.
+type Registry struct {
+	config     *config.Config
+	repository *repository.Repository
+	entries    []*registryEntry
+	health     *healthTracker
+	rules      *rules.Set
+}
+
+type registryEntry struct {
+	name    string
+	weight  int
+	section string
+	// baseConfig is configuration with provider options
+	// merged in but without options of rules.
+	baseConfig *config.Config
+	// config is configuration provider is initialized with.
+	// It's replaced with baseConfig merged with options of
+	// matching rules before each Provide, and provider is
+	// initialized again if it has changed.
+	config   *config.Config
+	provider IProvider
+}
+
+// NewRegistry creates providers listed in configuration. Each provider
+// gets its own copy of configuration with its options merged in.
+// Returns error if configuration refers to unknown provider or
+// provider options or rules are malformed.
+func NewRegistry(config *config.Config) (*Registry, error) {
+	r := &Registry{config: config}
+
+	var err error
+	r.rules, err = rules.New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range config.Providers {
+		f, ok := factories[p.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider '%s'", p.Name)
+		}
+
+		entryConfig, err := config.WithOptions(f.section, p.Options)
+		if err != nil {
+			return nil, fmt.Errorf("[Options of provider '%s'] %v", p.Name, err)
+		}
+
+		// Options of rules are merged once to make sure
+		// they fit, so malformed ones are reported at start.
+		for _, options := range r.rules.OptionsOf(p.Name) {
+			if _, err := entryConfig.WithOptions(f.section, options); err != nil {
+				return nil, fmt.Errorf("[Rule options of provider '%s'] %v", p.Name, err)
+			}
+		}
+
+		currentConfig := *entryConfig
+
+		r.entries = append(r.entries, &registryEntry{
+			name:       p.Name,
+			weight:     p.Weight,
+			section:    f.section,
+			baseConfig: entryConfig,
+			config:     &currentConfig,
+			provider:   f.create(),
+		})
+	}
+
+	if len(r.entries) == 0 {
+		return nil, fmt.Errorf("no providers configured")
+	}
+
+	return r, nil
+}
+
+// Init initializes all registered providers and loads their health
+// state. Passed configuration is ignored because each provider uses
+// configuration prepared by NewRegistry.
+func (r *Registry) Init(_ *config.Config, repository *repository.Repository) {
+	coolDown, err := r.config.Fallback.CoolDown.ToTime()
+	if err != nil {
+		log.Printf("[Parse fallback cool-down] %v", err)
+		coolDown = 30 * time.Minute
+	}
+
+	r.repository = repository
+	r.health = newHealthTracker(repository, r.config.Fallback.FailureThreshold, coolDown)
+
+	for _, entry := range r.entries {
+		entry.provider.Init(entry.config, repository)
+	}
+}
+
+// Provide tries healthy providers one by one until one of them gives
+// wallpaper. The first one is chosen according to weights, the rest
+// follow in order they are listed in configuration. Wallpaper records
+// name of provider produced it. Provider having no more content is
+// not considered failed.
+//
+// If all providers failed, returned error is of the same kind as
+// errors of all providers if they agree and transient otherwise.
+func (r *Registry) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	kind := Transient
+	tried := 0
+
+	for _, entry := range r.chain(time.Now()) {
+		log.Printf("Trying provider '%s'...", entry.name)
+
+		r.applyRules(entry, time.Now())
+
+		wallpaper, err := entry.provider.Provide(ctx)
+		if ctx.Err() != nil {
+			return nil, transient("Provide", ctx.Err())
+		}
+
+		if err != nil {
+			log.Printf("[Provider '%s'] %v (%s)", entry.name, err, KindOf(err))
+
+			if KindOf(err) != NoMoreContent {
+				r.health.recordFailure(entry.name, time.Now())
+			}
+
+			if tried == 0 {
+				kind = KindOf(err)
+			} else if kind != KindOf(err) {
+				kind = Transient
+			}
+			tried++
+
+			continue
+		}
+
+		r.health.recordSuccess(entry.name)
+		wallpaper.Provider = entry.name
+
+		return wallpaper, nil
+	}
+
+	return nil, &Error{
+		Kind: kind,
+		Op:   "Provide",
+		Err:  errors.New("all providers failed or are unhealthy"),
+	}
+}
+
+// applyRules replaces configuration of provider with its base
+// configuration merged with options of rules matching at moment now.
+// Providers compute some of options once in Init (e.g. git checkout
+// path), so provider is initialized again if configuration changes.
+// Registry without rules leaves configuration as it is.
+func (r *Registry) applyRules(entry *registryEntry, now time.Time) {
+	if r.rules == nil {
+		return
+	}
+
+	current := entry.baseConfig
+
+	for _, options := range r.rules.Options(entry.name, now) {
+		merged, err := current.WithOptions(entry.section, options)
+		if err != nil {
+			log.Printf("[Apply rule options to provider '%s'] %v", entry.name, err)
+			continue
+		}
+		current = merged
+	}
+
+	if reflect.DeepEqual(entry.config, current) {
+		return
+	}
+
+	log.Printf("Rules changed options of provider '%s'", entry.name)
+
+	*entry.config = *current
+	entry.provider.Init(entry.config, r.repository)
+}
+
+// chain returns healthy entries in order they should be tried.
+func (r *Registry) chain(now time.Time) []*registryEntry {
+	var healthy []*registryEntry
+	totalWeight := 0
+
+	for _, entry := range r.entries {
+		if r.health.isUnhealthy(entry.name, now) {
+			continue
+		}
+		healthy = append(healthy, entry)
+		totalWeight += entry.weight
+	}
+
+	if totalWeight == 0 {
+		return healthy
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	first := pick(healthy, rand.Intn(totalWeight))
+
+	chain := []*registryEntry{first}
+	for _, entry := range healthy {
+		if entry != first {
+			chain = append(chain, entry)
+		}
+	}
+
+	return chain
+}
+
+// pick returns entry corresponding to point n in [0, total weight)
+// on a line split into segments as long as entry weights.
+func pick(entries []*registryEntry, n int) *registryEntry {
+	for _, entry := range entries {
+		if n < entry.weight {
+			return entry
+		}
+		n -= entry.weight
+	}
+
+	return entries[len(entries)-1]
+}
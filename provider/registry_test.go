@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubProvider gives wallpaper with specified image buffer
+// or fails with specified error if buffer is empty. Counts calls.
+type stubProvider struct {
+	img   []byte
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Init(*config.Config, *repository.Repository) {}
+
+func (p *stubProvider) Provide(context.Context) (*repository.Wallpaper, error) {
+	p.calls++
+	if len(p.img) == 0 {
+		return nil, p.err
+	}
+	return &repository.Wallpaper{ImgBuffer: p.img}, nil
+}
+
+func TestNewRegistry(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Unsplash.AccessKey = "secret"
+	cfg.Unsplash.Query = "city"
+	cfg.Providers = []config.ProviderConfig{
+		{Name: "unsplash", Weight: 6, Options: json.RawMessage(`{"query": "forest"}`)},
+		{Name: "local", Weight: 3},
+		{Name: "generator", Weight: 1},
+	}
+
+	r, err := NewRegistry(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, r.entries, 3)
+
+	// Options are merged into provider's section.
+	assert.Equal(t, "forest", r.entries[0].config.Unsplash.Query)
+	assert.Equal(t, "secret", r.entries[0].config.Unsplash.AccessKey)
+	assert.Equal(t, "city", r.entries[1].config.Unsplash.Query)
+	assert.Equal(t, "city", cfg.Unsplash.Query)
+
+	assert.Equal(t, "unsplash", pick(r.entries, 0).name)
+	assert.Equal(t, "unsplash", pick(r.entries, 5).name)
+	assert.Equal(t, "local", pick(r.entries, 6).name)
+	assert.Equal(t, "local", pick(r.entries, 8).name)
+	assert.Equal(t, "generator", pick(r.entries, 9).name)
+
+	cfg.Providers = []config.ProviderConfig{{Name: "unknown"}}
+	_, err = NewRegistry(cfg)
+	assert.Error(t, err)
+
+	cfg.Providers = []config.ProviderConfig{{Name: "local", Options: json.RawMessage(`{"paths": 1}`)}}
+	_, err = NewRegistry(cfg)
+	assert.Error(t, err)
+}
+
+func TestRegistry_Provide(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Providers = []config.ProviderConfig{
+		{Name: "generator", Options: json.RawMessage(`{"width": 16, "height": 9}`)},
+	}
+	cfg.Fill()
+
+	r, err := NewRegistry(cfg)
+	assert.NoError(t, err)
+	r.Init(cfg, rep)
+
+	wallpaper, err := r.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, wallpaper.ImgBuffer)
+	assert.Equal(t, "generator", wallpaper.Provider)
+}
+
+func TestRegistry_ApplyRules(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Unsplash.Query = "city"
+	cfg.Providers = []config.ProviderConfig{
+		{Name: "unsplash", Options: json.RawMessage(`{"orientation": "portrait"}`)},
+	}
+	cfg.Rules = []config.RuleConfig{
+		{Providers: []string{"unsplash"}, Hours: "06:00-11:00", Options: json.RawMessage(`{"query": "forest"}`)},
+	}
+
+	r, err := NewRegistry(cfg)
+	assert.NoError(t, err)
+	entry := r.entries[0]
+
+	r.applyRules(entry, time.Date(2020, time.June, 22, 7, 0, 0, 0, time.Local))
+	assert.Equal(t, "forest", entry.config.Unsplash.Query)
+	assert.Equal(t, "portrait", entry.config.Unsplash.Orientation)
+
+	// Options of rule are dropped once it doesn't match.
+	r.applyRules(entry, time.Date(2020, time.June, 22, 12, 0, 0, 0, time.Local))
+	assert.Equal(t, "city", entry.config.Unsplash.Query)
+	assert.Equal(t, "portrait", entry.config.Unsplash.Orientation)
+
+	// Providers computing options in Init are initialized
+	// again when rules change their options.
+	cfg.Providers = append(cfg.Providers, config.ProviderConfig{
+		Name:    "git",
+		Options: json.RawMessage(`{"url": "https://example.com/walls.git"}`),
+	})
+	cfg.Rules = append(cfg.Rules, config.RuleConfig{
+		Providers: []string{"git"},
+		Weekdays:  []string{"sat", "sun"},
+		Options:   json.RawMessage(`{"url": "https://example.com/weekend.git", "pull_interval": "24h"}`),
+	})
+
+	r, err = NewRegistry(cfg)
+	assert.NoError(t, err)
+	r.Init(cfg, rep)
+	gitEntry := r.entries[1]
+	gitProvider := gitEntry.provider.(*GitProvider)
+
+	// June 20, 2020 is Saturday.
+	r.applyRules(gitEntry, time.Date(2020, time.June, 20, 12, 0, 0, 0, time.Local))
+	assert.Equal(t, filepath.Join(cfg.Git.CheckoutsPath, checkoutName("https://example.com/weekend.git")), gitProvider.checkoutPath)
+	assert.Equal(t, 24*time.Hour, gitProvider.pullInterval)
+
+	r.applyRules(gitEntry, time.Date(2020, time.June, 22, 12, 0, 0, 0, time.Local))
+	assert.Equal(t, filepath.Join(cfg.Git.CheckoutsPath, checkoutName("https://example.com/walls.git")), gitProvider.checkoutPath)
+	assert.Equal(t, time.Hour, gitProvider.pullInterval)
+
+	cfg.Rules = cfg.Rules[:1]
+	cfg.Rules[0].Options = json.RawMessage(`{"query": 1}`)
+	_, err = NewRegistry(cfg)
+	assert.Error(t, err)
+
+	cfg.Rules[0].Hours = "morning"
+	_, err = NewRegistry(cfg)
+	assert.Error(t, err)
+}
+
+func TestRegistry_ProvideFallback(t *testing.T) {
+	failing := &stubProvider{err: transient("", errors.New("timeout"))}
+	working := &stubProvider{img: []byte("ok")}
+
+	cfg := config.NewDefault()
+	cfg.Fallback.FailureThreshold = 2
+	cfg.Fallback.CoolDown = "1h"
+
+	newRegistry := func() *Registry {
+		r := &Registry{
+			config: cfg,
+			entries: []*registryEntry{
+				{name: "failing", weight: 1, provider: failing},
+				{name: "working", weight: 0, provider: working},
+			},
+		}
+		r.Init(cfg, rep)
+		return r
+	}
+
+	r := newRegistry()
+
+	for i := 0; i < 3; i++ {
+		wallpaper, err := r.Provide(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("ok"), wallpaper.ImgBuffer)
+		assert.Equal(t, "working", wallpaper.Provider)
+	}
+
+	// Failing provider is skipped after two failures.
+	assert.Equal(t, 2, failing.calls)
+	assert.Equal(t, 3, working.calls)
+	assert.True(t, r.health.isUnhealthy("failing", time.Now()))
+	assert.False(t, r.health.isUnhealthy("failing", time.Now().Add(2*time.Hour)))
+
+	// Health state survives restart.
+	r = newRegistry()
+	_, _ = r.Provide(context.Background())
+	assert.Equal(t, 2, failing.calls)
+
+	// Provider failing after cool-down goes back to cool-down at once.
+	r.health.health["failing"].UnhealthyUntil = 0
+	_, _ = r.Provide(context.Background())
+	assert.Equal(t, 3, failing.calls)
+	assert.True(t, r.health.isUnhealthy("failing", time.Now()))
+
+	// Provider having nothing new is not considered failed.
+	working.img = nil
+	working.err = noMoreContent("", "nothing new")
+	_, err := r.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+	assert.False(t, r.health.isUnhealthy("working", time.Now()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.health.health["failing"].UnhealthyUntil = 0
+	_, err = r.Provide(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 3, r.health.health["failing"].Failures)
+}
@@ -0,0 +1,328 @@
+package provider
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// palette returns n harmonious colors: hues are spread
+// around random base hue with random saturation and value.
+func palette(rng *rand.Rand, n int) []color.RGBA {
+	baseHue := rng.Float64() * 360
+	spread := 20 + rng.Float64()*60
+	saturation := 0.45 + rng.Float64()*0.4
+	value := 0.55 + rng.Float64()*0.4
+
+	colors := make([]color.RGBA, n)
+	for i := range colors {
+		hue := math.Mod(baseHue+float64(i)*spread, 360)
+		v := value * (1 - 0.25*float64(i)/float64(n))
+		colors[i] = hsv(hue, saturation, v)
+	}
+
+	return colors
+}
+
+// hsv converts color from HSV (hue in degrees, saturation
+// and value in [0, 1]) to RGB.
+func hsv(h, s, v float64) color.RGBA {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8((r + m) * 255),
+		G: uint8((g + m) * 255),
+		B: uint8((b + m) * 255),
+		A: 255,
+	}
+}
+
+// gradientAt returns color at position t in [0, 1] of gradient
+// with evenly distributed color stops.
+func gradientAt(stops []color.RGBA, t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t))
+	if len(stops) == 1 {
+		return stops[0]
+	}
+
+	pos := t * float64(len(stops)-1)
+	i := int(pos)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+
+	return mix(stops[i], stops[i+1], pos-float64(i))
+}
+
+// mix linearly interpolates between colors a and b.
+func mix(a, b color.RGBA, t float64) color.RGBA {
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t)
+	}
+
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: 255}
+}
+
+// shade makes color lighter (factor > 1) or darker (factor < 1).
+func shade(c color.RGBA, factor float64) color.RGBA {
+	scale := func(x uint8) uint8 {
+		return uint8(math.Max(0, math.Min(255, float64(x)*factor)))
+	}
+
+	return color.RGBA{R: scale(c.R), G: scale(c.G), B: scale(c.B), A: 255}
+}
+
+func renderLinearGradient(rng *rand.Rand, img *image.RGBA) {
+	stops := palette(rng, 2+rng.Intn(2))
+	angle := rng.Float64() * 2 * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+
+	// Projections of image corners give range of gradient axis.
+	length := math.Abs(w*dx) + math.Abs(h*dy)
+	start := math.Min(0, w*dx) + math.Min(0, h*dy)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			t := (float64(x)*dx + float64(y)*dy - start) / length
+			img.SetRGBA(x, y, gradientAt(stops, t))
+		}
+	}
+}
+
+func renderRadialGradient(rng *rand.Rand, img *image.RGBA) {
+	stops := palette(rng, 2+rng.Intn(2))
+
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	cx, cy := w*rng.Float64(), h*rng.Float64()
+
+	// Radius reaches the farthest corner.
+	radius := math.Max(
+		math.Max(math.Hypot(cx, cy), math.Hypot(w-cx, cy)),
+		math.Max(math.Hypot(cx, h-cy), math.Hypot(w-cx, h-cy)),
+	)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			t := math.Hypot(float64(x)-cx, float64(y)-cy) / radius
+			img.SetRGBA(x, y, gradientAt(stops, t))
+		}
+	}
+}
+
+func renderNoise(rng *rand.Rand, img *image.RGBA) {
+	stops := palette(rng, 3)
+	noise := newPerlin(rng)
+
+	bounds := img.Bounds()
+	// Scale makes noise features size proportional to image size.
+	scale := (2 + rng.Float64()*4) / float64(bounds.Dx())
+	offsetX, offsetY := rng.Float64()*256, rng.Float64()*256
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// Fractal noise rarely leaves [-0.5, 0.5], so it's
+			// stretched to use whole gradient.
+			n := noise.fractal(float64(x)*scale+offsetX, float64(y)*scale+offsetY, 4)
+			img.SetRGBA(x, y, gradientAt(stops, 0.5+n))
+		}
+	}
+}
+
+func renderLowPoly(rng *rand.Rand, img *image.RGBA) {
+	stops := palette(rng, 3)
+	angle := rng.Float64() * 2 * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+
+	columns := 8 + rng.Intn(10)
+	cell := w / float64(columns)
+	rows := int(math.Ceil(h/cell)) + 1
+
+	// Grid points are jittered to make triangles irregular.
+	// Border points stay on image edges.
+	points := make([][]point, rows+1)
+	for row := range points {
+		points[row] = make([]point, columns+1)
+		for column := range points[row] {
+			p := point{float64(column) * cell, float64(row) * cell}
+			if column > 0 && column < columns {
+				p.x += (rng.Float64() - 0.5) * cell * 0.8
+			}
+			if row > 0 && row < rows {
+				p.y += (rng.Float64() - 0.5) * cell * 0.8
+			}
+			points[row][column] = p
+		}
+	}
+
+	length := math.Abs(w*dx) + math.Abs(h*dy)
+	start := math.Min(0, w*dx) + math.Min(0, h*dy)
+
+	fill := func(a, b, c point) {
+		cx, cy := (a.x+b.x+c.x)/3, (a.y+b.y+c.y)/3
+		base := gradientAt(stops, (cx*dx+cy*dy-start)/length)
+		fillTriangle(img, a, b, c, shade(base, 0.85+rng.Float64()*0.3))
+	}
+
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			tl, tr := points[row][column], points[row][column+1]
+			bl, br := points[row+1][column], points[row+1][column+1]
+
+			if rng.Intn(2) == 0 {
+				fill(tl, tr, br)
+				fill(tl, br, bl)
+			} else {
+				fill(tl, tr, bl)
+				fill(tr, br, bl)
+			}
+		}
+	}
+}
+
+func renderStripes(rng *rand.Rand, img *image.RGBA) {
+	colors := palette(rng, 3+rng.Intn(3))
+	angle := rng.Float64() * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+
+	bounds := img.Bounds()
+	width := float64(bounds.Dx()) / float64(8+rng.Intn(24))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			t := (float64(x)*dx + float64(y)*dy) / width
+			i := int(math.Floor(t)) % len(colors)
+			if i < 0 {
+				i += len(colors)
+			}
+			img.SetRGBA(x, y, colors[i])
+		}
+	}
+}
+
+type point struct {
+	x, y float64
+}
+
+// fillTriangle paints pixels whose centers are inside triangle abc.
+func fillTriangle(img *image.RGBA, a, b, c point, col color.RGBA) {
+	bounds := img.Bounds()
+	minX := int(math.Max(float64(bounds.Min.X), math.Floor(math.Min(a.x, math.Min(b.x, c.x)))))
+	maxX := int(math.Min(float64(bounds.Max.X-1), math.Ceil(math.Max(a.x, math.Max(b.x, c.x)))))
+	minY := int(math.Max(float64(bounds.Min.Y), math.Floor(math.Min(a.y, math.Min(b.y, c.y)))))
+	maxY := int(math.Min(float64(bounds.Max.Y-1), math.Ceil(math.Max(a.y, math.Max(b.y, c.y)))))
+
+	edge := func(p, q point, x, y float64) float64 {
+		return (q.x-p.x)*(y-p.y) - (q.y-p.y)*(x-p.x)
+	}
+
+	area := edge(a, b, c.x, c.y)
+	if area == 0 {
+		return
+	}
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			w0 := edge(b, c, px, py) * area
+			w1 := edge(c, a, px, py) * area
+			w2 := edge(a, b, px, py) * area
+			if w0 >= 0 && w1 >= 0 && w2 >= 0 {
+				img.SetRGBA(x, y, col)
+			}
+		}
+	}
+}
+
+// perlin is 2D Perlin noise generator.
+type perlin struct {
+	perm [512]int
+}
+
+func newPerlin(rng *rand.Rand) *perlin {
+	p := &perlin{}
+	for i, v := range rng.Perm(256) {
+		p.perm[i] = v
+		p.perm[i+256] = v
+	}
+
+	return p
+}
+
+// noise returns noise value in approximately [-1, 1].
+func (p *perlin) noise(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	xi, yi := int(fx)&255, int(fy)&255
+	xf, yf := x-fx, y-fy
+
+	u, v := fade(xf), fade(yf)
+
+	aa := p.perm[p.perm[xi]+yi]
+	ab := p.perm[p.perm[xi]+yi+1]
+	ba := p.perm[p.perm[xi+1]+yi]
+	bb := p.perm[p.perm[xi+1]+yi+1]
+
+	x1 := lerp(grad(aa, xf, yf), grad(ba, xf-1, yf), u)
+	x2 := lerp(grad(ab, xf, yf-1), grad(bb, xf-1, yf-1), u)
+
+	return lerp(x1, x2, v)
+}
+
+// fractal sums octaves of noise with decreasing amplitude.
+func (p *perlin) fractal(x, y float64, octaves int) float64 {
+	sum, amplitude, frequency, norm := 0.0, 1.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		sum += p.noise(x*frequency, y*frequency) * amplitude
+		norm += amplitude
+		amplitude /= 2
+		frequency *= 2
+	}
+
+	return sum / norm
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + t*(b-a)
+}
+
+func grad(hash int, x, y float64) float64 {
+	switch hash & 3 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	default:
+		return -x - y
+	}
+}
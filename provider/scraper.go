@@ -0,0 +1,360 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// pagePlaceholder is replaced with page number in list page URL template.
+	pagePlaceholder = "{page}"
+	// scraperMaxAttempts is maximum number of list pages
+	// looked at per one change.
+	scraperMaxAttempts = 10
+)
+
+// builtinSites are site definitions shipped with blider.
+var builtinSites = map[string]config.ScraperSite{
+	"simpledesktops": {
+		Name:               "simpledesktops",
+		ListURL:            "http://simpledesktops.com/browse/{page}",
+		FirstPage:          1,
+		ItemSelector:       ".desktops .edge",
+		DetailLinkSelector: ".desktop a",
+		ImageLinkSelector:  ".desktop-detail .desktop a",
+		TitleSelector:      ".desktop h2",
+		AuthorSelector:     ".desktop .creator a",
+	},
+}
+
+// ScraperProvider is provider of images scraped from
+// web sites described by config.ScraperSite definitions.
+type ScraperProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	client     *httpclient.Client
+	seen       *seenIndex
+	sites      []*scraperSite
+	// pageCountRefresh is period after which page
+	// counts are discovered again.
+	pageCountRefresh time.Duration
+}
+
+// scraperSite is site definition with state collected
+// while scraping.
+type scraperSite struct {
+	config.ScraperSite
+	// lastPage is number of the last list page known so far.
+	lastPage int
+	// discoveredAt is a time lastPage was discovered. Zero
+	// time means page count has to be discovered.
+	discoveredAt time.Time
+}
+
+func (p *ScraperProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing ScraperProvider...")
+	p.init(config, repository, "scraper", config.Scraper.Sites)
+}
+
+// init sets provider up to scrape sites. Items already given
+// are tracked in seen index under specified provider name.
+func (p *ScraperProvider) init(
+	config *config.Config,
+	repository *repository.Repository,
+	name string,
+	sites []config.ScraperSite,
+) {
+	p.config = config
+	p.repository = repository
+	p.client = newHTTPClient(config)
+	p.seen = newSeenIndex(repository, name, config)
+	p.sites = nil
+
+	var err error
+	p.pageCountRefresh, err = config.Scraper.PageCountRefresh.ToTime()
+	if err != nil {
+		log.Printf("[Parse page count refresh period] %v", err)
+		p.pageCountRefresh = 24 * time.Hour
+	}
+
+	for _, site := range sites {
+		site, err := resolveSite(site)
+		if err != nil {
+			log.Printf("[Init site '%s'] %v", site.Name, err)
+			continue
+		}
+
+		p.sites = append(p.sites, p.loadPageCount(site))
+	}
+}
+
+// resolveSite replaces reference to built-in site with its
+// definition and checks that definition is complete.
+func resolveSite(site config.ScraperSite) (config.ScraperSite, error) {
+	if builtin, ok := builtinSites[site.Name]; ok && len(site.ListURL) == 0 {
+		site = builtin
+	}
+
+	if !strings.Contains(site.ListURL, pagePlaceholder) {
+		return site, errors.New("list page URL template has no {page} placeholder")
+	}
+
+	if len(site.ItemSelector) == 0 || len(site.ImageLinkSelector) == 0 {
+		return site, errors.New("item and image link selectors are required")
+	}
+
+	if site.FirstPage <= 0 {
+		site.FirstPage = 1
+	}
+
+	return site, nil
+}
+
+// Provide tries to parse and download image not seen yet
+// from random configured site.
+func (p *ScraperProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	if len(p.sites) == 0 {
+		return nil, permanent("Provide", errors.New("no sites configured"))
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	site := p.sites[rand.Intn(len(p.sites))]
+
+	p.refreshPageCount(ctx, site)
+
+	wallpaper, err := site.provide(ctx, p.client, p.seen)
+	if err != nil {
+		return nil, wrap(fmt.Sprintf("Provide from %s", site.Name), err)
+	}
+
+	return wallpaper, nil
+}
+
+func (s *scraperSite) provide(
+	ctx context.Context,
+	client *httpclient.Client,
+	seen *seenIndex,
+) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", s.Name)
+
+	var wallpaper *repository.Wallpaper
+	visited := make(map[int]bool)
+	seenPages := 0
+
+	for attempt := 0; wallpaper == nil && attempt < scraperMaxAttempts; attempt++ {
+		pageCount := s.lastPage - s.FirstPage + 1
+		if len(visited) >= pageCount {
+			break
+		}
+
+		rand.Seed(time.Now().UnixNano())
+		pageNum := s.FirstPage + rand.Intn(pageCount)
+		for visited[pageNum] {
+			pageNum = s.FirstPage + rand.Intn(pageCount)
+		}
+		visited[pageNum] = true
+
+		url := listPageURL(s.ListURL, pageNum)
+		log.Printf("Fetching %s...", url)
+
+		var hasItems bool
+		var err error
+		wallpaper, hasItems, err = s.tryToPickFrom(ctx, client, seen, url)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasItems {
+			if wallpaper == nil {
+				seenPages++
+			}
+			continue
+		}
+
+		// Empty page means site has fewer pages than known,
+		// so pages beyond it are not looked at anymore and page
+		// count is discovered again on next change.
+		if pageNum > s.FirstPage && s.LastPage <= 0 {
+			s.lastPage = pageNum - 1
+			s.discoveredAt = time.Time{}
+		}
+	}
+
+	if wallpaper == nil {
+		if seenPages > 0 {
+			return nil, noMoreContent("", "all items on %d visited pages have already been seen", seenPages)
+		}
+		return nil, noMoreContent("", "no wallpapers found after %d attempts", len(visited))
+	}
+
+	return wallpaper, nil
+}
+
+// tryToPickFrom downloads random wallpaper not seen yet from
+// list page. Returns nil wallpaper if page does not exist, has
+// no items or all its items have already been seen. hasItems
+// tells the latter from the former.
+func (s *scraperSite) tryToPickFrom(
+	ctx context.Context,
+	client *httpclient.Client,
+	seen *seenIndex,
+	url string,
+) (wallpaper *repository.Wallpaper, hasItems bool, err error) {
+	doc, err := getDocument(ctx, client, url)
+	if err != nil {
+		if ctx.Err() == nil && KindOf(err) == Permanent {
+			log.Printf("[Fetch %s] %v", url, err)
+			return nil, false, nil
+		}
+		return nil, false, wrap(fmt.Sprintf("Fetch %s", url), err)
+	}
+
+	items := doc.Find(s.ItemSelector)
+	if items.Length() == 0 {
+		return nil, false, nil
+	}
+
+	item, originURL, err := s.pickUnseen(items, seen, url)
+	if err != nil || item == nil {
+		return nil, true, err
+	}
+
+	// Wallpaper page is item itself if site has no detail pages.
+	pageURL := url
+	page := item
+
+	if len(s.DetailLinkSelector) > 0 {
+		pageURL = originURL
+		log.Printf("Fetching image from wallpaper page: %s", pageURL)
+
+		detail, err := getDocument(ctx, client, pageURL)
+		if err != nil {
+			return nil, true, wrap(fmt.Sprintf("Provide wallpaper from %s", pageURL), err)
+		}
+		page = detail.Selection
+	}
+
+	imgURL, ok := linkOf(page.Find(s.ImageLinkSelector))
+	if !ok {
+		return nil, true, permanent(
+			fmt.Sprintf("Provide wallpaper from %s", pageURL),
+			errors.New("failed to extract image url"),
+		)
+	}
+	imgURL = resolveURL(pageURL, imgURL)
+	log.Printf("Image URL: %s", imgURL)
+
+	filename, img, err := downloadImageToBuffer(ctx, client, imgURL)
+	if err != nil {
+		return nil, true, wrap(fmt.Sprintf("Provide wallpaper from %s", pageURL), err)
+	}
+
+	seen.markSeen(originURL)
+
+	title := s.find(s.TitleSelector, item, page)
+	authorLink := s.find(s.AuthorSelector, item, page)
+
+	author := strings.TrimSpace(authorLink.Text())
+	if len(author) == 0 {
+		author = "Unknown"
+	}
+
+	authorURL, _ := authorLink.Attr("href")
+	if len(authorURL) > 0 {
+		authorURL = resolveURL(pageURL, authorURL)
+	}
+
+	return &repository.Wallpaper{
+		OriginURL:      originURL,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          strings.TrimSpace(title.Text()),
+		Author:         author,
+		AuthorURL:      authorURL,
+		ImgBuffer:      img,
+	}, true, nil
+}
+
+// pickUnseen returns random item of list page that has not been
+// seen yet along with its origin URL: URL of wallpaper page or URL
+// of image if site has no detail pages. Returns nil item if all
+// items have already been seen.
+func (s *scraperSite) pickUnseen(
+	items *goquery.Selection,
+	seen *seenIndex,
+	url string,
+) (*goquery.Selection, string, error) {
+	rand.Seed(time.Now().UnixNano())
+
+	for _, itemIndex := range rand.Perm(items.Length()) {
+		item := items.Eq(itemIndex)
+
+		var originURL string
+		var ok bool
+		if len(s.DetailLinkSelector) > 0 {
+			originURL, ok = item.Find(s.DetailLinkSelector).Attr("href")
+			if !ok {
+				return nil, "", permanent(
+					fmt.Sprintf("Provide from %s", url),
+					errors.New("failed to find link to wallpaper page"),
+				)
+			}
+		} else {
+			originURL, ok = linkOf(item.Find(s.ImageLinkSelector))
+			if !ok {
+				return nil, "", permanent(
+					fmt.Sprintf("Provide from %s", url),
+					errors.New("failed to extract image url"),
+				)
+			}
+		}
+		originURL = resolveURL(url, originURL)
+
+		isSeen, err := seen.isSeen(originURL)
+		if err != nil {
+			return nil, "", transient(fmt.Sprintf("Check whether %s is seen", originURL), err)
+		}
+
+		if !isSeen {
+			log.Printf("Parsing HTML element #%d...", itemIndex+1)
+			return item, originURL, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// find applies selector to list item and then to wallpaper
+// page if nothing found in item.
+func (s *scraperSite) find(selector string, item, page *goquery.Selection) *goquery.Selection {
+	if len(selector) == 0 {
+		return &goquery.Selection{}
+	}
+
+	found := item.Find(selector).First()
+	if found.Length() == 0 && page != item {
+		found = page.Find(selector).First()
+	}
+
+	return found
+}
+
+// linkOf returns href of link or src of image.
+func linkOf(selection *goquery.Selection) (string, bool) {
+	selection = selection.First()
+
+	if href, ok := selection.Attr("href"); ok {
+		return href, true
+	}
+
+	return selection.Attr("src")
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScraperProvider_Provide(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/gallery/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body><ul class="grid">
+			<li class="tile">
+				<a class="open" href="/wallpaper/42">Open</a>
+				<span class="name">Aurora</span>
+			</li>
+		</ul></body></html>`)
+	})
+	mux.HandleFunc("/wallpaper/42", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><body>
+			<img class="full" src="../files/aurora.png">
+			<p class="by"><a href="/users/kate">Kate</a></p>
+		</body></html>`)
+	})
+	mux.HandleFunc("/files/aurora.png", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("aurora"))
+	})
+
+	cfg := config.NewDefault()
+	cfg.Scraper.Sites = []config.ScraperSite{
+		{
+			Name:               "test",
+			ListURL:            server.URL + "/gallery/{page}",
+			FirstPage:          1,
+			LastPage:           3,
+			ItemSelector:       ".grid .tile",
+			DetailLinkSelector: "a.open",
+			ImageLinkSelector:  "img.full",
+			TitleSelector:      ".name",
+			AuthorSelector:     ".by a",
+		},
+		// Incomplete definition is skipped.
+		{Name: "broken", ListURL: server.URL},
+	}
+
+	p := &ScraperProvider{}
+	p.Init(cfg, rep)
+	assert.Len(t, p.sites, 1)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("aurora"), wallpaper.ImgBuffer)
+	assert.Equal(t, "Aurora", wallpaper.Title)
+	assert.Equal(t, "Kate", wallpaper.Author)
+	assert.Equal(t, server.URL+"/users/kate", wallpaper.AuthorURL)
+	assert.Equal(t, server.URL+"/wallpaper/42", wallpaper.OriginURL)
+
+	// All pages list the same wallpaper which is seen now.
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, NoMoreContent, KindOf(err))
+}
+
+func TestResolveSite(t *testing.T) {
+	site, err := resolveSite(config.ScraperSite{Name: "simpledesktops"})
+	assert.NoError(t, err)
+	assert.Equal(t, builtinSites["simpledesktops"], site)
+
+	_, err = resolveSite(config.ScraperSite{Name: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestScraperProvider_PageCount(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		page, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/page/"))
+		if err != nil || page > 100 {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `<div class="pages"><a href="/page/2">2</a><a href="/page/37">Last</a></div>`)
+		if page <= 37 {
+			_, _ = fmt.Fprint(w, `<div class="item"></div>`)
+		}
+	}))
+	defer server.Close()
+
+	site := config.ScraperSite{
+		Name:              "pages",
+		ListURL:           server.URL + "/page/{page}",
+		FirstPage:         1,
+		ItemSelector:      ".item",
+		ImageLinkSelector: "img",
+	}
+
+	lastPage, err := discoverLastPage(context.Background(), newHTTPClient(config.NewDefault()), &site, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 37, lastPage)
+
+	site.PaginationSelector = ".pages a"
+	requests = 0
+	lastPage, err = discoverLastPage(context.Background(), newHTTPClient(config.NewDefault()), &site, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 37, lastPage)
+	assert.Equal(t, 1, requests)
+
+	// Discovered page count survives restart and is refreshed
+	// after refresh period.
+	cfg := config.NewDefault()
+	cfg.Scraper.Sites = []config.ScraperSite{site}
+	cfg.Scraper.PageCountRefresh = "1h"
+
+	p := &ScraperProvider{}
+	p.Init(cfg, rep)
+	assert.Equal(t, cfg.MaxFetchPages, p.sites[0].lastPage)
+
+	p.refreshPageCount(context.Background(), p.sites[0])
+	assert.Equal(t, 37, p.sites[0].lastPage)
+
+	p = &ScraperProvider{}
+	p.Init(cfg, rep)
+	assert.Equal(t, 37, p.sites[0].lastPage)
+
+	requests = 0
+	p.refreshPageCount(context.Background(), p.sites[0])
+	assert.Equal(t, 0, requests)
+
+	p.sites[0].discoveredAt = time.Now().Add(-2 * time.Hour)
+	p.refreshPageCount(context.Background(), p.sites[0])
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// seenIndex tells whether provider has already given item.
+// Items are identified by origin URL of wallpaper.
+type seenIndex struct {
+	repository *repository.Repository
+	provider   string
+	// repeatAfter is period after which item may be given
+	// again. Zero means never.
+	repeatAfter time.Duration
+}
+
+func newSeenIndex(rep *repository.Repository, provider string, config *config.Config) *seenIndex {
+	repeatAfter := time.Duration(0)
+	if config.AllowRepeatsAfter > 0 {
+		repeatAfter = time.Duration(config.AllowRepeatsAfter) * 24 * time.Hour
+	}
+
+	return &seenIndex{
+		repository:  rep,
+		provider:    provider,
+		repeatAfter: repeatAfter,
+	}
+}
+
+// isSeen reports whether item must be skipped because provider
+// has already given it and it can't be repeated yet.
+func (i *seenIndex) isSeen(originURL string) (bool, error) {
+	if i.repository == nil {
+		return false, nil
+	}
+
+	since := uint(0)
+	if i.repeatAfter > 0 {
+		since = uint(time.Now().Add(-i.repeatAfter).Unix())
+	}
+
+	return i.repository.IsSeen(i.provider, originURL, since)
+}
+
+// markSeen records that provider has given item. Failure is only
+// logged because wallpaper is worth showing anyway.
+func (i *seenIndex) markSeen(originURL string) {
+	if i.repository == nil {
+		return
+	}
+
+	if err := i.repository.MarkSeen(i.provider, originURL, uint(time.Now().Unix())); err != nil {
+		log.Printf("[Mark %s seen] %v", originURL, err)
+	}
+}
+
+// pickUnseenFile returns random file whose item has not been given
+// yet. Files are user's own collection, so once all of them have been
+// given, provider starts over regardless of repeat policy: all of
+// them are forgotten, but the one given last is not picked right
+// away. originURL maps file path to item origin URL.
+func (i *seenIndex) pickUnseenFile(paths []string, originURL func(string) string) (string, error) {
+	var unseen []string
+	for _, p := range paths {
+		seen, err := i.isSeen(originURL(p))
+		if err != nil {
+			return "", transient(fmt.Sprintf("Check whether %s is seen", p), err)
+		}
+
+		if !seen {
+			unseen = append(unseen, p)
+		}
+	}
+
+	if len(unseen) == 0 && len(paths) > 0 {
+		log.Printf("All %d images have been seen, starting over...", len(paths))
+
+		last, err := i.repository.LastSeen(i.provider)
+		if err != nil {
+			return "", transient("Find last seen image", err)
+		}
+
+		var forgotten []string
+		for _, p := range paths {
+			forgotten = append(forgotten, originURL(p))
+			if originURL(p) != last || len(paths) == 1 {
+				unseen = append(unseen, p)
+			}
+		}
+
+		if err := i.repository.ForgetSeen(i.provider, forgotten); err != nil {
+			return "", transient("Forget seen images", err)
+		}
+	}
+
+	if len(unseen) == 0 {
+		return "", nil
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	return unseen[rand.Intn(len(unseen))], nil
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	unsplashAPIURL = "https://api.unsplash.com"
+	// unsplashReferral must be appended to links pointing to
+	// Unsplash according to API guidelines.
+	unsplashReferral = "utm_source=blider&utm_medium=referral"
+	// unsplashBatchSize is number of random photos requested
+	// at once, so already seen ones can be skipped.
+	unsplashBatchSize = 10
+)
+
+// UnsplashProvider is provider of random photos taken
+// from https://unsplash.com via Unsplash API.
+type UnsplashProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+	apiURL     string
+}
+
+type unsplashPhoto struct {
+	ID             string `json:"id"`
+	Description    string `json:"description"`
+	AltDescription string `json:"alt_description"`
+	URLs           struct {
+		Full string `json:"full"`
+	} `json:"urls"`
+	Links struct {
+		HTML             string `json:"html"`
+		DownloadLocation string `json:"download_location"`
+	} `json:"links"`
+	User struct {
+		Name  string `json:"name"`
+		Links struct {
+			HTML string `json:"html"`
+		} `json:"links"`
+	} `json:"user"`
+}
+
+func (p *UnsplashProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing UnsplashProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "unsplash", config)
+	p.client = newHTTPClient(config)
+
+	if len(p.apiURL) == 0 {
+		p.apiURL = unsplashAPIURL
+	}
+}
+
+// Provide requests random photos matching configured filters
+// from Unsplash API and downloads the first one not seen yet.
+func (p *UnsplashProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", p.apiURL)
+
+	options := p.config.Unsplash
+	if len(options.AccessKey) == 0 {
+		return nil, permanent("Provide", errors.New("Unsplash access key is not configured"))
+	}
+
+	query := url.Values{}
+	if len(options.Collections) > 0 {
+		query.Set("collections", strings.Join(options.Collections, ","))
+	}
+	if len(options.Topics) > 0 {
+		query.Set("topics", strings.Join(options.Topics, ","))
+	}
+	if len(options.Query) > 0 {
+		query.Set("query", options.Query)
+	}
+	if len(options.Orientation) > 0 {
+		query.Set("orientation", options.Orientation)
+	}
+	query.Set("count", strconv.Itoa(unsplashBatchSize))
+
+	header := http.Header{}
+	header.Set("Accept-Version", "v1")
+	header.Set("Authorization", fmt.Sprintf("Client-ID %s", options.AccessKey))
+
+	photoURL := fmt.Sprintf("%s/photos/random?%s", p.apiURL, query.Encode())
+
+	var photos []*unsplashPhoto
+	if err := getJSON(ctx, p.client, photoURL, header, &photos); err != nil {
+		return nil, wrap(fmt.Sprintf("Provide %s", photoURL), err)
+	}
+
+	var photo *unsplashPhoto
+	for _, candidate := range photos {
+		seen, err := p.seen.isSeen(withUnsplashReferral(candidate.Links.HTML))
+		if err != nil {
+			return nil, transient(fmt.Sprintf("Check whether photo %s is seen", candidate.ID), err)
+		}
+
+		if !seen {
+			photo = candidate
+			break
+		}
+	}
+
+	if photo == nil {
+		return nil, noMoreContent("Provide", "all %d random photos have already been seen", len(photos))
+	}
+
+	filename, img, err := downloadImageToBuffer(ctx, p.client, photo.URLs.Full)
+	if err != nil {
+		return nil, wrap(fmt.Sprintf("Provide photo %s", photo.ID), err)
+	}
+
+	// API guidelines require to notify Unsplash about each download.
+	if len(photo.Links.DownloadLocation) > 0 {
+		if err := getJSON(ctx, p.client, photo.Links.DownloadLocation, header, &struct{}{}); err != nil {
+			log.Printf("[Track download of photo %s] %v", photo.ID, err)
+		}
+	}
+
+	title := photo.Description
+	if len(title) == 0 {
+		title = photo.AltDescription
+	}
+
+	originURL := withUnsplashReferral(photo.Links.HTML)
+	p.seen.markSeen(originURL)
+
+	return &repository.Wallpaper{
+		OriginURL:      originURL,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          strings.TrimSpace(title),
+		Author:         photo.User.Name,
+		AuthorURL:      withUnsplashReferral(photo.User.Links.HTML),
+		ImgBuffer:      img,
+	}, nil
+}
+
+func withUnsplashReferral(link string) string {
+	if len(link) == 0 {
+		return link
+	}
+
+	if strings.Contains(link, "?") {
+		return fmt.Sprintf("%s&%s", link, unsplashReferral)
+	}
+
+	return fmt.Sprintf("%s?%s", link, unsplashReferral)
+}
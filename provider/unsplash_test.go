@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestUnsplashProvider_Provide(t *testing.T) {
+	var (
+		query      url.Values
+		authHeader string
+		tracked    bool
+	)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/photos/random", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		authHeader = r.Header.Get("Authorization")
+		_, _ = fmt.Fprintf(w, `[{
+			"id": "seen",
+			"urls": {"full": "%[1]s/images/photo-seen"},
+			"links": {"html": "https://unsplash.com/photos/seen"}
+		}, {
+			"id": "abc",
+			"description": null,
+			"alt_description": "green hills",
+			"urls": {"full": "%[1]s/images/photo-abc?fm=jpg"},
+			"links": {
+				"html": "https://unsplash.com/photos/abc",
+				"download_location": "%[1]s/photos/abc/download"
+			},
+			"user": {
+				"name": "Jane Doe",
+				"links": {"html": "https://unsplash.com/@jane"}
+			}
+		}]`, server.URL)
+	})
+	mux.HandleFunc("/photos/abc/download", func(w http.ResponseWriter, r *http.Request) {
+		tracked = true
+		_, _ = fmt.Fprint(w, `{"url": ""}`)
+	})
+	mux.HandleFunc("/images/photo-abc", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte{0xff, 0xd8, 0xff})
+	})
+
+	cfg := config.NewDefault()
+	cfg.Unsplash.AccessKey = "secret"
+	cfg.Unsplash.Collections = []string{"1", "2"}
+	cfg.Unsplash.Query = "forest"
+
+	p := &UnsplashProvider{apiURL: server.URL}
+	p.Init(cfg, rep)
+
+	assert.NoError(t, rep.MarkSeen("unsplash", "https://unsplash.com/photos/seen?"+unsplashReferral, 1))
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0xd8, 0xff}, wallpaper.ImgBuffer)
+	assert.Regexp(t, `-photo-abc\.jpg$`, wallpaper.Filename)
+	assert.Equal(t, "green hills", wallpaper.Title)
+	assert.Equal(t, "Jane Doe", wallpaper.Author)
+	assert.Equal(t, "https://unsplash.com/@jane?"+unsplashReferral, wallpaper.AuthorURL)
+	assert.Equal(t, "https://unsplash.com/photos/abc?"+unsplashReferral, wallpaper.OriginURL)
+
+	assert.Equal(t, "Client-ID secret", authHeader)
+	assert.Equal(t, "1,2", query.Get("collections"))
+	assert.Equal(t, "forest", query.Get("query"))
+	assert.Equal(t, "landscape", query.Get("orientation"))
+	assert.True(t, tracked)
+}
+
+func TestUnsplashProvider_ProvideWithoutAccessKey(t *testing.T) {
+	p := &UnsplashProvider{apiURL: "http://127.0.0.1:0"}
+	p.Init(config.NewDefault(), nil)
+
+	_, err := p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+}
@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	wallhavenAPIURL  = "https://wallhaven.cc/api/v1"
+	wallhavenUserURL = "https://wallhaven.cc/user/"
+	// wallhavenTitleTags is number of tags wallpaper
+	// title is made of.
+	wallhavenTitleTags = 3
+)
+
+var (
+	// wallhavenCategories are category names in order
+	// of flags of "categories" search parameter.
+	wallhavenCategories = []string{"general", "anime", "people"}
+	// wallhavenPurities are purity names in order of
+	// flags of "purity" search parameter.
+	wallhavenPurities = []string{"sfw", "sketchy", "nsfw"}
+)
+
+// WallhavenProvider is provider of random wallpapers taken
+// from https://wallhaven.cc via Wallhaven API.
+type WallhavenProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+	apiURL     string
+}
+
+type wallhavenWallpaper struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Path       string `json:"path"`
+	DimensionX int    `json:"dimension_x"`
+	DimensionY int    `json:"dimension_y"`
+	Uploader   struct {
+		Username string `json:"username"`
+	} `json:"uploader"`
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+func (p *WallhavenProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing WallhavenProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "wallhaven", config)
+	p.client = newHTTPClient(config)
+
+	if len(p.apiURL) == 0 {
+		p.apiURL = wallhavenAPIURL
+	}
+}
+
+// Provide searches random wallpapers matching configured filters
+// via Wallhaven API and downloads the first one not seen yet.
+func (p *WallhavenProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", p.apiURL)
+
+	options := p.config.Wallhaven
+
+	apiKey, err := p.config.Secret("wallhaven_api_key")
+	if err != nil {
+		return nil, permanent("Read Wallhaven API key", err)
+	}
+
+	categories, err := flags(wallhavenCategories, options.Categories)
+	if err != nil {
+		return nil, permanent("Parse categories", err)
+	}
+
+	purity, err := flags(wallhavenPurities, options.Purity)
+	if err != nil {
+		return nil, permanent("Parse purity", err)
+	}
+
+	if strings.HasSuffix(purity, "1") && len(apiKey) == 0 {
+		return nil, permanent("Provide", errors.New("NSFW purity requires Wallhaven API key"))
+	}
+
+	query := url.Values{}
+	query.Set("sorting", "random")
+	query.Set("categories", categories)
+	query.Set("purity", purity)
+	if len(options.Query) > 0 {
+		query.Set("q", options.Query)
+	}
+	if len(options.Color) > 0 {
+		query.Set("colors", strings.TrimPrefix(options.Color, "#"))
+	}
+	if options.MinWidth > 0 || options.MinHeight > 0 {
+		query.Set("atleast", fmt.Sprintf("%dx%d", maxInt(options.MinWidth, 1), maxInt(options.MinHeight, 1)))
+	}
+	if len(options.Ratios) > 0 {
+		query.Set("ratios", strings.Join(options.Ratios, ","))
+	}
+	// API key is sent in header, so it doesn't get to
+	// logged URLs and cache.
+	header := http.Header{}
+	if len(apiKey) > 0 {
+		header.Set("X-API-Key", apiKey)
+	}
+
+	searchURL := fmt.Sprintf("%s/search?%s", p.apiURL, query.Encode())
+
+	var result struct {
+		Data []*wallhavenWallpaper `json:"data"`
+	}
+	if err := getJSON(ctx, p.client, searchURL, header, &result); err != nil {
+		return nil, wrap(fmt.Sprintf("Provide %s", p.apiURL), err)
+	}
+
+	var wallpaper *wallhavenWallpaper
+	for _, candidate := range result.Data {
+		seen, err := p.seen.isSeen(candidate.URL)
+		if err != nil {
+			return nil, transient(fmt.Sprintf("Check whether wallpaper %s is seen", candidate.ID), err)
+		}
+
+		if !seen {
+			wallpaper = candidate
+			break
+		}
+	}
+
+	if wallpaper == nil {
+		return nil, noMoreContent("Provide", "all %d found wallpapers have already been seen", len(result.Data))
+	}
+
+	filename, img, err := downloadImageToBuffer(ctx, p.client, wallpaper.Path)
+	if err != nil {
+		return nil, wrap(fmt.Sprintf("Provide wallpaper %s", wallpaper.ID), err)
+	}
+
+	p.seen.markSeen(wallpaper.URL)
+
+	// Search results have neither uploader nor tags, they
+	// are taken from wallpaper info.
+	info := &struct {
+		Data *wallhavenWallpaper `json:"data"`
+	}{Data: wallpaper}
+
+	infoURL := fmt.Sprintf("%s/w/%s", p.apiURL, wallpaper.ID)
+	if err := getJSON(ctx, p.client, infoURL, header, info); err != nil {
+		log.Printf("[Fetch info of wallpaper %s] %v", wallpaper.ID, err)
+	}
+
+	author := wallpaper.Uploader.Username
+	authorURL := ""
+	if len(author) > 0 {
+		authorURL = wallhavenUserURL + url.PathEscape(author)
+	} else {
+		author = "Unknown"
+	}
+
+	var tags []string
+	for i := 0; i < len(wallpaper.Tags) && i < wallhavenTitleTags; i++ {
+		tags = append(tags, wallpaper.Tags[i].Name)
+	}
+
+	return &repository.Wallpaper{
+		OriginURL:      wallpaper.URL,
+		Filename:       filename,
+		FetchTimestamp: uint(time.Now().Unix()),
+		Title:          strings.Join(tags, ", "),
+		Author:         author,
+		AuthorURL:      authorURL,
+		ImgBuffer:      img,
+	}, nil
+}
+
+// flags returns string of "0" and "1" telling which of names
+// are selected. Empty selection means all names.
+func flags(names []string, selected []string) (string, error) {
+	result := []byte(strings.Repeat("0", len(names)))
+	if len(selected) == 0 {
+		return strings.Repeat("1", len(names)), nil
+	}
+
+	for _, value := range selected {
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		found := false
+		for i, name := range names {
+			if name == value {
+				result[i] = '1'
+				found = true
+			}
+		}
+
+		if !found {
+			return "", fmt.Errorf("unknown value '%s', expected one of %s", value, strings.Join(names, ", "))
+		}
+	}
+
+	return string(result), nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
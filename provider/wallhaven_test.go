@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWallhavenProvider_Provide(t *testing.T) {
+	var query url.Values
+	var apiKeys, requestURIs []string
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKeys = append(apiKeys, r.Header.Get("X-API-Key"))
+		requestURIs = append(requestURIs, r.RequestURI)
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = fmt.Fprintf(w, `{"data": [{
+			"id": "k7q9",
+			"url": "https://wallhaven.cc/w/k7q9",
+			"path": "%s/full/wallhaven-k7q9.png"
+		}]}`, server.URL)
+	})
+	mux.HandleFunc("/w/k7q9", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"data": {
+			"id": "k7q9",
+			"url": "https://wallhaven.cc/w/k7q9",
+			"uploader": {"username": "mike"},
+			"tags": [{"name": "mountains"}, {"name": "snow"}]
+		}}`)
+	})
+	mux.HandleFunc("/full/wallhaven-k7q9.png", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("mountains"))
+	})
+
+	dir, err := ioutil.TempDir("", "blider_wallhaven_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := config.NewDefault()
+	cfg.SecretsPath = filepath.Join(dir, "secrets.json")
+	cfg.Wallhaven.Query = "mountains"
+	cfg.Wallhaven.Color = "#336600"
+	cfg.Wallhaven.MinWidth = 2560
+	cfg.Wallhaven.Ratios = []string{"16x9"}
+	cfg.Wallhaven.Categories = []string{"general", "people"}
+	cfg.Wallhaven.Purity = []string{"sfw", "nsfw"}
+
+	p := &WallhavenProvider{apiURL: server.URL}
+	p.Init(cfg, rep)
+
+	// NSFW wallpapers require API key.
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+
+	err = ioutil.WriteFile(cfg.SecretsPath, []byte(`{"wallhaven_api_key": "secret"}`), 0600)
+	assert.NoError(t, err)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("mountains"), wallpaper.ImgBuffer)
+	assert.Regexp(t, `-wallhaven-k7q9\.png$`, wallpaper.Filename)
+	assert.Equal(t, "mountains, snow", wallpaper.Title)
+	assert.Equal(t, "mike", wallpaper.Author)
+	assert.Equal(t, "https://wallhaven.cc/user/mike", wallpaper.AuthorURL)
+	assert.Equal(t, "https://wallhaven.cc/w/k7q9", wallpaper.OriginURL)
+
+	assert.Equal(t, "mountains", query.Get("q"))
+	assert.Equal(t, "336600", query.Get("colors"))
+	assert.Equal(t, "2560x1", query.Get("atleast"))
+	assert.Equal(t, "16x9", query.Get("ratios"))
+	assert.Equal(t, "101", query.Get("categories"))
+	assert.Equal(t, "101", query.Get("purity"))
+	// API key is sent to API only and never in URL.
+	assert.Equal(t, []string{"secret", "", "secret"}, apiKeys)
+	for _, uri := range requestURIs {
+		assert.NotContains(t, uri, "secret")
+	}
+
+	cfg.Wallhaven.Purity = []string{"lewd"}
+	_, err = p.Provide(context.Background())
+	assert.Equal(t, Permanent, KindOf(err))
+}
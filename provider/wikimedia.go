@@ -0,0 +1,213 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/httpclient"
+	"github.com/ildarkarymoff/blider/repository"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	wikimediaAPIURL  = "https://commons.wikimedia.org/w/api.php"
+	wikimediaPotdFmt = "https://commons.wikimedia.org/wiki/Template:Potd/%s"
+)
+
+// wikimediaFirstDay is the date since Wikimedia Commons
+// has "Picture of the Day" for every day.
+var wikimediaFirstDay = time.Date(2007, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// WikimediaProvider is provider of "Picture of the Day"
+// images taken from https://commons.wikimedia.org.
+type WikimediaProvider struct {
+	config     *config.Config
+	repository *repository.Repository
+	seen       *seenIndex
+	client     *httpclient.Client
+	apiURL     string
+}
+
+type wikimediaResponse struct {
+	Query struct {
+		Pages []struct {
+			Title     string `json:"title"`
+			ImageInfo []struct {
+				URL         string                              `json:"url"`
+				MIME        string                              `json:"mime"`
+				ExtMetadata map[string]wikimediaExtMetadataItem `json:"extmetadata"`
+			} `json:"imageinfo"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+type wikimediaExtMetadataItem struct {
+	Value string `json:"value"`
+}
+
+func (p *WikimediaProvider) Init(config *config.Config, repository *repository.Repository) {
+	log.Println("Initializing WikimediaProvider...")
+	p.config = config
+	p.repository = repository
+	p.seen = newSeenIndex(repository, "wikimedia", config)
+	p.client = newHTTPClient(config)
+
+	if len(p.apiURL) == 0 {
+		p.apiURL = wikimediaAPIURL
+	}
+}
+
+// Provide walks back day by day starting from today and
+// downloads the first picture of the day that has not been
+// fetched yet. Days with videos, audio or vector images
+// are skipped.
+func (p *WikimediaProvider) Provide(ctx context.Context) (*repository.Wallpaper, error) {
+	log.Printf("Fetching from %s...", p.apiURL)
+
+	date := time.Now()
+	attempts := 0
+
+	var lastErr error
+
+	for ; attempts < p.config.Wikimedia.MaxAttempts && !date.Before(wikimediaFirstDay); date = date.AddDate(0, 0, -1) {
+		day := date.Format("2006-01-02")
+		originURL := fmt.Sprintf(wikimediaPotdFmt, day)
+
+		seen, err := p.seen.isSeen(originURL)
+		if err != nil {
+			return nil, transient(fmt.Sprintf("Check whether %s is seen", originURL), err)
+		}
+
+		if seen {
+			continue
+		}
+
+		attempts++
+
+		wallpaper, err := p.pickFrom(ctx, day)
+		if err != nil {
+			lastErr = wrap(fmt.Sprintf("Provide %s", originURL), err)
+			if ctx.Err() != nil || KindOf(err) == Permanent {
+				return nil, lastErr
+			}
+			log.Println(lastErr)
+			continue
+		}
+
+		if wallpaper == nil {
+			log.Printf("Skipping %s: picture of the day is not an image", day)
+			continue
+		}
+
+		p.seen.markSeen(originURL)
+
+		wallpaper.OriginURL = originURL
+		return wallpaper, nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, noMoreContent("Provide", "no picture found after %d attempts", attempts)
+}
+
+// pickFrom downloads picture of the day for specified day.
+// Returns nil wallpaper if the day has no suitable image.
+func (p *WikimediaProvider) pickFrom(ctx context.Context, day string) (*repository.Wallpaper, error) {
+	query := url.Values{}
+	query.Set("action", "query")
+	query.Set("format", "json")
+	query.Set("formatversion", "2")
+	query.Set("generator", "images")
+	query.Set("titles", fmt.Sprintf("Template:Potd/%s", day))
+	query.Set("prop", "imageinfo")
+	query.Set("iiprop", "url|mime|extmetadata")
+	query.Set("iiextmetadatafilter", "Artist|LicenseShortName|LicenseUrl|ObjectName")
+
+	var resp wikimediaResponse
+	if err := getJSON(ctx, p.client, fmt.Sprintf("%s?%s", p.apiURL, query.Encode()), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, page := range resp.Query.Pages {
+		if len(page.ImageInfo) == 0 {
+			continue
+		}
+
+		info := page.ImageInfo[0]
+		if !strings.HasPrefix(info.MIME, "image/") || info.MIME == "image/svg+xml" {
+			continue
+		}
+
+		filename, img, err := downloadImageToBuffer(ctx, p.client, info.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		title := htmlToText(info.ExtMetadata["ObjectName"].Value)
+		if len(title) == 0 {
+			title = strings.TrimPrefix(page.Title, "File:")
+			title = strings.TrimSuffix(title, path.Ext(title))
+		}
+
+		author, authorURL := wikimediaAttribution(info.ExtMetadata)
+
+		return &repository.Wallpaper{
+			Filename:       filename,
+			FetchTimestamp: uint(time.Now().Unix()),
+			Title:          title,
+			Author:         author,
+			AuthorURL:      authorURL,
+			ImgBuffer:      img,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// wikimediaAttribution builds author credit like "John Doe (CC BY-SA 4.0)"
+// from file metadata. Author URL points to author's page if artist
+// metadata has a link or to license otherwise.
+func wikimediaAttribution(metadata map[string]wikimediaExtMetadataItem) (string, string) {
+	artist := metadata["Artist"].Value
+	license := htmlToText(metadata["LicenseShortName"].Value)
+
+	author := htmlToText(artist)
+	if len(author) == 0 {
+		author = "Unknown"
+	}
+	if len(license) > 0 {
+		author = fmt.Sprintf("%s (%s)", author, license)
+	}
+
+	authorURL := metadata["LicenseUrl"].Value
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(artist))
+	if err == nil {
+		if href, ok := doc.Find("a").Attr("href"); ok {
+			authorURL = href
+		}
+	}
+
+	if strings.HasPrefix(authorURL, "//") {
+		authorURL = fmt.Sprintf("https:%s", authorURL)
+	}
+
+	return author, authorURL
+}
+
+// htmlToText strips HTML markup and collapses whitespaces.
+func htmlToText(html string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return strings.TrimSpace(html)
+	}
+
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
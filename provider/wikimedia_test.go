@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWikimediaProvider_Provide(t *testing.T) {
+	assert.NoError(t, rep.ClearHistory())
+
+	today := time.Now().Format("2006-01-02")
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api.php", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("titles") == "Template:Potd/"+today {
+			_, _ = fmt.Fprint(w, `{"query": {"pages": [{
+				"title": "File:Eclipse.webm",
+				"imageinfo": [{"url": "https://example.org/Eclipse.webm", "mime": "video/webm"}]
+			}]}}`)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"query": {"pages": [{
+			"title": "File:Mountain lake.jpg",
+			"imageinfo": [{
+				"url": "%s/files/Mountain_lake.jpg",
+				"mime": "image/jpeg",
+				"extmetadata": {
+					"Artist": {"value": "<a href=\"//commons.wikimedia.org/wiki/User:Jane\" title=\"User:Jane\">Jane</a>"},
+					"LicenseShortName": {"value": "CC BY-SA 4.0"},
+					"LicenseUrl": {"value": "https://creativecommons.org/licenses/by-sa/4.0"}
+				}
+			}]
+		}]}}`, server.URL)
+	})
+	mux.HandleFunc("/files/Mountain_lake.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("lake"))
+	})
+
+	p := &WikimediaProvider{apiURL: server.URL + "/api.php"}
+	p.Init(config.NewDefault(), rep)
+
+	wallpaper, err := p.Provide(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("lake"), wallpaper.ImgBuffer)
+	assert.Equal(t, "Mountain lake", wallpaper.Title)
+	assert.Equal(t, "Jane (CC BY-SA 4.0)", wallpaper.Author)
+	assert.Equal(t, "https://commons.wikimedia.org/wiki/User:Jane", wallpaper.AuthorURL)
+	assert.Equal(t, fmt.Sprintf(wikimediaPotdFmt, yesterday), wallpaper.OriginURL)
+}
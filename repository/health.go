@@ -0,0 +1,45 @@
+package repository
+
+// ProviderHealth is health state of provider persisted between restarts.
+type ProviderHealth struct {
+	// Name is provider name.
+	Name string
+	// Failures is number of consecutive failures.
+	Failures int
+	// UnhealthyUntil is a time until provider must not be used.
+	UnhealthyUntil uint
+}
+
+// GetProvidersHealth returns health state of all providers
+// that have ever failed.
+func (r *Repository) GetProvidersHealth() (map[string]*ProviderHealth, error) {
+	rows, err := r.db.Query("select name, failures, unhealthy_until from provider_health")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	health := make(map[string]*ProviderHealth)
+
+	for rows.Next() {
+		h := &ProviderHealth{}
+		if err := rows.Scan(&h.Name, &h.Failures, &h.UnhealthyUntil); err != nil {
+			return nil, err
+		}
+		health[h.Name] = h
+	}
+
+	return health, rows.Err()
+}
+
+// SaveProviderHealth inserts or updates provider health state.
+func (r *Repository) SaveProviderHealth(health *ProviderHealth) error {
+	query := `insert or replace into provider_health (
+				name,
+				failures,
+				unhealthy_until)
+			values (?, ?, ?)`
+
+	_, err := r.db.Exec(query, health.Name, health.Failures, health.UnhealthyUntil)
+	return err
+}
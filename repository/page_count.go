@@ -0,0 +1,44 @@
+package repository
+
+import "database/sql"
+
+// PageCount is discovered number of list pages of scraped site.
+type PageCount struct {
+	// ListURL is list page URL template identifying site.
+	ListURL string
+	// LastPage is number of the last list page.
+	LastPage int
+	// UpdatedAt is a time when page count was discovered.
+	UpdatedAt uint
+}
+
+// GetPageCount returns page count of site having specified
+// list page URL template or nil if it has not been discovered yet.
+func (r *Repository) GetPageCount(listURL string) (*PageCount, error) {
+	c := &PageCount{}
+
+	err := r.db.QueryRow(
+		"select list_url, last_page, updated_at from page_counts where list_url = ?",
+		listURL,
+	).Scan(&c.ListURL, &c.LastPage, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// SavePageCount inserts or updates page count of site.
+func (r *Repository) SavePageCount(count *PageCount) error {
+	query := `insert or replace into page_counts (
+				list_url,
+				last_page,
+				updated_at)
+			values (?, ?, ?)`
+
+	_, err := r.db.Exec(query, count.ListURL, count.LastPage, count.UpdatedAt)
+	return err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Enqueue appends prefetched wallpaper to the end of queue.
+// Returns ID of wallpaper in queue.
+func (r *Repository) Enqueue(wallpaper *Wallpaper) (int64, error) {
+	query := `insert into queue (
+				origin_url,
+				filename,
+				fetch_timestamp,
+				title,
+				author,
+				author_url,
+				local_path,
+				provider)
+			values (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(
+		query,
+		wallpaper.OriginURL,
+		wallpaper.Filename,
+		wallpaper.FetchTimestamp,
+		wallpaper.Title,
+		wallpaper.Author,
+		wallpaper.AuthorURL,
+		wallpaper.LocalPath,
+		wallpaper.Provider,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Dequeue removes the first wallpaper from queue and returns it.
+// Returns nil if queue is empty.
+func (r *Repository) Dequeue() (*Wallpaper, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("select %s from queue order by id limit 1", wallpaperColumns)
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	wallpapers, err := scanWallpapers(rows)
+	_ = rows.Close()
+	if err != nil || len(wallpapers) == 0 {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	w := wallpapers[0]
+
+	if _, err := tx.Exec("delete from queue where id = ?", w.ID); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// QueueLength returns number of wallpapers in queue.
+func (r *Repository) QueueLength() (int, error) {
+	var length int
+	err := r.db.QueryRow("select count(*) from queue").Scan(&length)
+	return length, err
+}
+
+// GetQueue returns queued wallpapers in order they were enqueued.
+func (r *Repository) GetQueue() ([]*Wallpaper, error) {
+	query := fmt.Sprintf("select %s from queue order by id", wallpaperColumns)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWallpapers(rows)
+}
+
+// scanWallpapers reads all rows selected with wallpaperColumns.
+func scanWallpapers(rows *sql.Rows) ([]*Wallpaper, error) {
+	var wallpapers []*Wallpaper
+
+	for rows.Next() {
+		w, err := scanWallpaper(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallpapers = append(wallpapers, w)
+	}
+
+	return wallpapers, rows.Err()
+}
@@ -26,6 +26,68 @@ type Wallpaper struct {
 	AuthorURL string
 	// ImgBuffer contains image bytes taken from provider.
 	ImgBuffer []byte
+	// LocalPath is absolute path to image file that is not managed
+	// by local image repository (e.g. file in user's wallpapers folder).
+	// Such wallpapers are not saved to and never removed from local
+	// image repository.
+	LocalPath string
+	// Provider is name of provider that produced wallpaper.
+	Provider string
+}
+
+// wallpaperColumns is list of history and queue table columns
+// in order expected by scanWallpaper.
+const wallpaperColumns = `id,
+	origin_url,
+	filename,
+	fetch_timestamp,
+	title,
+	author,
+	author_url,
+	local_path,
+	provider`
+
+// tables is list of tables added after the first release.
+// Open creates missing ones.
+var tables = []string{
+	`create table if not exists provider_health (
+		name TEXT PRIMARY KEY,
+		failures INTEGER NOT NULL DEFAULT 0,
+		unhealthy_until INTEGER NOT NULL DEFAULT 0
+	)`,
+	`create table if not exists seen (
+		provider TEXT NOT NULL,
+		origin_url TEXT NOT NULL,
+		seen_at INTEGER NOT NULL,
+		PRIMARY KEY (provider, origin_url)
+	)`,
+	`create table if not exists page_counts (
+		list_url TEXT PRIMARY KEY,
+		last_page INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`,
+	`create table if not exists queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		origin_url TEXT NOT NULL,
+		filename TEXT NOT NULL,
+		fetch_timestamp INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		author TEXT NOT NULL,
+		author_url TEXT NOT NULL,
+		local_path TEXT NOT NULL,
+		provider TEXT NOT NULL
+	)`,
+}
+
+// migrations is list of columns added to history table after the
+// first release. Open adds missing columns to databases created
+// by older versions.
+var migrations = []struct {
+	column     string
+	definition string
+}{
+	{"local_path", "TEXT NOT NULL DEFAULT ''"},
+	{"provider", "TEXT NOT NULL DEFAULT ''"},
 }
 
 // Repository allows other program modules to make operations with local SQLite database.
@@ -55,6 +117,11 @@ func Open(dbPath string) (*Repository, error) {
 		return nil, err
 	}
 
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("[Migrate SQLite Database] %v", err)
+	}
+
 	return &Repository{
 		db: db,
 	}, nil
@@ -89,6 +156,74 @@ func createDatabase(dbPath string) error {
 	return nil
 }
 
+func migrate(db *sql.DB) error {
+	var seenTables int
+	query := "select count(*) from sqlite_master where type = 'table' and name = 'seen'"
+	if err := db.QueryRow(query).Scan(&seenTables); err != nil {
+		return err
+	}
+
+	for _, query := range tables {
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	rows, err := db.Query("pragma table_info(history)")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    bool
+			dfltValue  interface{}
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if columns[m.column] {
+			continue
+		}
+
+		query := fmt.Sprintf("alter table history add column %s %s", m.column, m.definition)
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	// Index of seen items is filled from history once it's created.
+	// History recorded before providers were introduced belongs to
+	// simpledesktops, the only provider of that time.
+	if seenTables == 0 {
+		query := `insert or ignore into seen (provider, origin_url, seen_at)
+				select
+					case when provider = '' then 'simpledesktops' else provider end,
+					origin_url,
+					fetch_timestamp
+				from history
+				where origin_url is not null`
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Close ...
 func (r *Repository) Close() error {
 	return r.db.Close()
@@ -96,25 +231,28 @@ func (r *Repository) Close() error {
 
 // AddWallpaper ...
 func (r *Repository) AddWallpaper(wallpaper *Wallpaper) (int64, error) {
-	queryFormat := `insert into history (
-						origin_url,
-						filename,
-						fetch_timestamp,
-						title,
-						author,
-						author_url)
-					values ("%s", "%s", %d, %q, %q, %q)`
-	query := fmt.Sprintf(
-		queryFormat,
+	query := `insert into history (
+				origin_url,
+				filename,
+				fetch_timestamp,
+				title,
+				author,
+				author_url,
+				local_path,
+				provider)
+			values (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(
+		query,
 		wallpaper.OriginURL,
 		wallpaper.Filename,
 		wallpaper.FetchTimestamp,
 		wallpaper.Title,
 		wallpaper.Author,
 		wallpaper.AuthorURL,
+		wallpaper.LocalPath,
+		wallpaper.Provider,
 	)
-
-	result, err := r.db.Exec(query)
 	if err != nil {
 		return 0, err
 	}
@@ -129,10 +267,9 @@ func (r *Repository) AddWallpaper(wallpaper *Wallpaper) (int64, error) {
 
 // GetWallpaper ...
 func (r *Repository) GetWallpaper(id int) (*Wallpaper, error) {
-	queryFormat := "select * from history where id = %d"
-	query := fmt.Sprintf(queryFormat, id)
+	query := fmt.Sprintf("select %s from history where id = ?", wallpaperColumns)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -141,12 +278,8 @@ func (r *Repository) GetWallpaper(id int) (*Wallpaper, error) {
 	var wallpapers []*Wallpaper
 
 	for rows.Next() {
-		w := &Wallpaper{}
-		if err := rows.Scan(&w.ID,
-			&w.OriginURL,
-			&w.Filename,
-			&w.FetchTimestamp,
-		); err != nil {
+		w, err := scanWallpaper(rows)
+		if err != nil {
 			return nil, err
 		}
 		wallpapers = append(wallpapers, w)
@@ -166,58 +299,21 @@ func (r *Repository) ClearHistory() error {
 	return err
 }
 
-// IsOriginURLAlreadyPresented is legacy method used in past for checking if
-// wallpaper has already downloaded earlier. Now I consider removing this.
-func (r *Repository) IsOriginURLAlreadyPresented(originUrl string) (bool, error) {
-	queryFormat := "select * from history where origin_url = %q"
-	query := fmt.Sprintf(queryFormat, originUrl)
-
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return false, err
-	}
-
-	var wallpapers []*Wallpaper
-
-	for rows.Next() {
-		w := &Wallpaper{}
-		if err := rows.Scan(&w.ID,
-			&w.OriginURL,
-			&w.Filename,
-			&w.FetchTimestamp,
-			&w.Title,
-			&w.Author,
-			&w.AuthorURL,
-		); err != nil {
-			return false, err
-		}
-		wallpapers = append(wallpapers, w)
-	}
-
-	return len(wallpapers) != 0, nil
-}
-
 // GetWallpapers ...
 func (r *Repository) GetWallpapers() ([]*Wallpaper, error) {
-	query := "select * from history"
+	query := fmt.Sprintf("select %s from history", wallpaperColumns)
 
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return []*Wallpaper{}, err
 	}
+	defer rows.Close()
 
 	var wallpapers Wallpapers
 
 	for rows.Next() {
-		w := &Wallpaper{}
-		if err := rows.Scan(&w.ID,
-			&w.OriginURL,
-			&w.Filename,
-			&w.FetchTimestamp,
-			&w.Title,
-			&w.Author,
-			&w.AuthorURL,
-		); err != nil {
+		w, err := scanWallpaper(rows)
+		if err != nil {
 			return []*Wallpaper{}, err
 		}
 
@@ -228,6 +324,25 @@ func (r *Repository) GetWallpapers() ([]*Wallpaper, error) {
 	return wallpapers, nil
 }
 
+// scanWallpaper reads wallpaper from row selected with wallpaperColumns.
+func scanWallpaper(rows *sql.Rows) (*Wallpaper, error) {
+	w := &Wallpaper{}
+	if err := rows.Scan(&w.ID,
+		&w.OriginURL,
+		&w.Filename,
+		&w.FetchTimestamp,
+		&w.Title,
+		&w.Author,
+		&w.AuthorURL,
+		&w.LocalPath,
+		&w.Provider,
+	); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
 type Wallpapers []*Wallpaper
 
 func (w Wallpapers) Len() int {
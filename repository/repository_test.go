@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -39,3 +40,141 @@ func TestOpen(t *testing.T) {
 	assert.Empty(t, rep)
 	assert.Error(t, err)
 }
+
+func TestOpen_Migrate(t *testing.T) {
+	legacyDbPath := filepath.Join(filepath.Dir(dbPath), "blider_legacy_test.sqlite")
+	defer os.Remove(legacyDbPath)
+
+	db, err := sql.Open("sqlite3", legacyDbPath)
+	assert.NoError(t, err)
+
+	_, err = db.Exec(`CREATE TABLE history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		origin_url TEXT,
+		filename TEXT,
+		fetch_timestamp INTEGER,
+		title TEXT,
+		author TEXT,
+		author_url TEXT
+	)`)
+	assert.NoError(t, err)
+
+	_, err = db.Exec(`insert into history (origin_url, filename, fetch_timestamp, title, author, author_url)
+		values ("https://example.org", "old.png", 1, "Old", "", "")`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	rep, err := Open(legacyDbPath)
+	assert.NoError(t, err)
+	defer rep.Close()
+
+	_, err = rep.AddWallpaper(&Wallpaper{
+		Filename:       "new.png",
+		FetchTimestamp: 2,
+		LocalPath:      "/home/user/Pictures/new.png",
+	})
+	assert.NoError(t, err)
+
+	wallpapers, err := rep.GetWallpapers()
+	assert.NoError(t, err)
+	assert.Len(t, wallpapers, 2)
+	assert.Equal(t, "/home/user/Pictures/new.png", wallpapers[0].LocalPath)
+	assert.Equal(t, "", wallpapers[1].LocalPath)
+
+	// Legacy history is seen by simpledesktops.
+	seen, err := rep.IsSeen("simpledesktops", "https://example.org", 0)
+	assert.NoError(t, err)
+	assert.True(t, seen)
+
+	// Migrated database is opened again without errors.
+	rep2, err := Open(legacyDbPath)
+	assert.NoError(t, err)
+	assert.NoError(t, rep2.Close())
+}
+
+func TestRepository_MarkSeen(t *testing.T) {
+	rep, err := Open(dbPath)
+	assert.NoError(t, err)
+	defer rep.Close()
+
+	assert.NoError(t, rep.MarkSeen("bing", "https://example.org/1", 100))
+
+	seen, err := rep.IsSeen("bing", "https://example.org/1", 0)
+	assert.NoError(t, err)
+	assert.True(t, seen)
+
+	// Seen items are tracked per provider.
+	seen, err = rep.IsSeen("apod", "https://example.org/1", 0)
+	assert.NoError(t, err)
+	assert.False(t, seen)
+
+	seen, err = rep.IsSeen("bing", "https://example.org/1", 101)
+	assert.NoError(t, err)
+	assert.False(t, seen)
+
+	assert.NoError(t, rep.MarkSeen("bing", "https://example.org/1", 200))
+	seen, err = rep.IsSeen("bing", "https://example.org/1", 101)
+	assert.NoError(t, err)
+	assert.True(t, seen)
+}
+
+func TestRepository_ForgetSeen(t *testing.T) {
+	rep, err := Open(dbPath)
+	assert.NoError(t, err)
+	defer rep.Close()
+
+	last, err := rep.LastSeen("local")
+	assert.NoError(t, err)
+	assert.Empty(t, last)
+
+	assert.NoError(t, rep.MarkSeen("local", "file:///a.png", 100))
+	assert.NoError(t, rep.MarkSeen("local", "file:///b.png", 100))
+	assert.NoError(t, rep.MarkSeen("git", "file:///c.png", 300))
+
+	// The latest of items seen at the same moment is the last one.
+	last, err = rep.LastSeen("local")
+	assert.NoError(t, err)
+	assert.Equal(t, "file:///b.png", last)
+
+	assert.NoError(t, rep.ForgetSeen("local", []string{"file:///a.png", "file:///c.png"}))
+
+	seen, err := rep.IsSeen("local", "file:///a.png", 0)
+	assert.NoError(t, err)
+	assert.False(t, seen)
+
+	seen, err = rep.IsSeen("git", "file:///c.png", 0)
+	assert.NoError(t, err)
+	assert.True(t, seen)
+}
+
+func TestRepository_Dequeue(t *testing.T) {
+	rep, err := Open(dbPath)
+	assert.NoError(t, err)
+	defer rep.Close()
+
+	for _, title := range []string{"first", "second"} {
+		_, err := rep.Enqueue(&Wallpaper{Title: title, Filename: title + ".png", Provider: "bing"})
+		assert.NoError(t, err)
+	}
+
+	length, err := rep.QueueLength()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, length)
+
+	queue, err := rep.GetQueue()
+	assert.NoError(t, err)
+	assert.Len(t, queue, 2)
+
+	w, err := rep.Dequeue()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", w.Title)
+	assert.Equal(t, "bing", w.Provider)
+
+	w, err = rep.Dequeue()
+	assert.NoError(t, err)
+	assert.Equal(t, "second", w.Title)
+
+	w, err = rep.Dequeue()
+	assert.NoError(t, err)
+	assert.Nil(t, w)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+// IsSeen reports whether provider has given item with specified
+// origin URL at moment since (Unix time) or later. Zero since
+// means any moment.
+func (r *Repository) IsSeen(provider, originURL string, since uint) (bool, error) {
+	var count int
+	query := "select count(*) from seen where provider = ? and origin_url = ? and seen_at >= ?"
+	if err := r.db.QueryRow(query, provider, originURL, since).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count != 0, nil
+}
+
+// MarkSeen records that provider has given item with specified
+// origin URL at moment seenAt (Unix time).
+func (r *Repository) MarkSeen(provider, originURL string, seenAt uint) error {
+	query := `insert or replace into seen (
+				provider,
+				origin_url,
+				seen_at)
+			values (?, ?, ?)`
+
+	_, err := r.db.Exec(query, provider, originURL, seenAt)
+	return err
+}
+
+// LastSeen returns origin URL of item provider has given most
+// recently or empty string if provider has given nothing yet.
+func (r *Repository) LastSeen(provider string) (string, error) {
+	var originURL string
+	query := "select origin_url from seen where provider = ? order by seen_at desc, rowid desc limit 1"
+	err := r.db.QueryRow(query, provider).Scan(&originURL)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return originURL, err
+}
+
+// ForgetSeen removes items with specified origin URLs from
+// items given by provider, so provider may give them again.
+func (r *Repository) ForgetSeen(provider string, originURLs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, originURL := range originURLs {
+		if _, err := tx.Exec("delete from seen where provider = ? and origin_url = ?", provider, originURL); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
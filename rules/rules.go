@@ -0,0 +1,213 @@
+// Package rules picks provider option overrides depending on
+// time of day, weekday, month and daylight at user's location.
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/config"
+	"strings"
+	"time"
+)
+
+const (
+	sunDay   = "day"
+	sunNight = "night"
+)
+
+// Set is list of rules evaluated before each change.
+type Set struct {
+	rules     []*rule
+	latitude  float64
+	longitude float64
+}
+
+type rule struct {
+	// providers is set of provider names rule applies to.
+	// Empty set means all providers.
+	providers map[string]bool
+	hours     *hourRange
+	weekdays  map[time.Weekday]bool
+	months    map[time.Month]bool
+	sun       string
+	options   json.RawMessage
+}
+
+// hourRange is range of minutes since midnight. Range
+// ending before it starts spans midnight.
+type hourRange struct {
+	from int
+	to   int
+}
+
+// New parses rules of configuration. Returns error if some
+// of rules are malformed.
+func New(config *config.Config) (*Set, error) {
+	s := &Set{
+		latitude:  config.Location.Latitude,
+		longitude: config.Location.Longitude,
+	}
+
+	for i, ruleConfig := range config.Rules {
+		r, err := s.parse(&ruleConfig)
+		if err != nil {
+			return nil, fmt.Errorf("[Rule #%d] %v", i+1, err)
+		}
+		s.rules = append(s.rules, r)
+	}
+
+	return s, nil
+}
+
+func (s *Set) parse(ruleConfig *config.RuleConfig) (*rule, error) {
+	r := &rule{
+		providers: make(map[string]bool),
+		options:   ruleConfig.Options,
+	}
+
+	for _, name := range ruleConfig.Providers {
+		r.providers[strings.TrimSpace(name)] = true
+	}
+
+	if len(strings.TrimSpace(ruleConfig.Hours)) > 0 {
+		hours, err := parseHourRange(ruleConfig.Hours)
+		if err != nil {
+			return nil, err
+		}
+		r.hours = hours
+	}
+
+	if len(ruleConfig.Weekdays) > 0 {
+		r.weekdays = make(map[time.Weekday]bool)
+		for _, name := range ruleConfig.Weekdays {
+			day, ok := parseName(name, 7, func(i int) string { return time.Weekday(i).String() })
+			if !ok {
+				return nil, fmt.Errorf("unknown weekday '%s'", name)
+			}
+			r.weekdays[time.Weekday(day)] = true
+		}
+	}
+
+	if len(ruleConfig.Months) > 0 {
+		r.months = make(map[time.Month]bool)
+		for _, name := range ruleConfig.Months {
+			month, ok := parseName(name, 12, func(i int) string { return time.Month(i + 1).String() })
+			if !ok {
+				return nil, fmt.Errorf("unknown month '%s'", name)
+			}
+			r.months[time.Month(month+1)] = true
+		}
+	}
+
+	r.sun = strings.ToLower(strings.TrimSpace(ruleConfig.Sun))
+	switch r.sun {
+	case "":
+	case sunDay, sunNight:
+		if s.latitude == 0 && s.longitude == 0 {
+			return nil, errors.New("sun condition requires location")
+		}
+	default:
+		return nil, fmt.Errorf("unknown sun condition '%s', expected 'day' or 'night'", r.sun)
+	}
+
+	return r, nil
+}
+
+// parseHourRange parses range in "HH:MM-HH:MM" format.
+func parseHourRange(value string) (*hourRange, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed hour range '%s'", value)
+	}
+
+	var minutes [2]int
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("malformed hour range '%s'", value)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+
+	return &hourRange{from: minutes[0], to: minutes[1]}, nil
+}
+
+// parseName returns index of name among count names produced by
+// nameOf. Names are case-insensitive and may be abbreviated to
+// three letters.
+func parseName(name string, count int, nameOf func(i int) string) (int, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return 0, false
+	}
+
+	for i := 0; i < count; i++ {
+		if strings.HasPrefix(strings.ToLower(nameOf(i)), name) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// Options returns options of rules applying to provider having
+// specified name at moment now in order they are listed.
+func (s *Set) Options(provider string, now time.Time) []json.RawMessage {
+	var options []json.RawMessage
+
+	for _, r := range s.rules {
+		if r.appliesTo(provider) && s.matches(r, now) {
+			options = append(options, r.options)
+		}
+	}
+
+	return options
+}
+
+// OptionsOf returns options of all rules applying to provider
+// regardless of their conditions.
+func (s *Set) OptionsOf(provider string) []json.RawMessage {
+	var options []json.RawMessage
+
+	for _, r := range s.rules {
+		if r.appliesTo(provider) {
+			options = append(options, r.options)
+		}
+	}
+
+	return options
+}
+
+func (r *rule) appliesTo(provider string) bool {
+	return len(r.providers) == 0 || r.providers[provider]
+}
+
+// matches reports whether all conditions of rule are met at moment now.
+func (s *Set) matches(r *rule, now time.Time) bool {
+	if r.hours != nil && !r.hours.contains(now.Hour()*60+now.Minute()) {
+		return false
+	}
+
+	if r.weekdays != nil && !r.weekdays[now.Weekday()] {
+		return false
+	}
+
+	if r.months != nil && !r.months[now.Month()] {
+		return false
+	}
+
+	if len(r.sun) > 0 && IsDaylight(now, s.latitude, s.longitude) != (r.sun == sunDay) {
+		return false
+	}
+
+	return true
+}
+
+func (h *hourRange) contains(minute int) bool {
+	if h.from <= h.to {
+		return minute >= h.from && minute < h.to
+	}
+
+	return minute >= h.from || minute < h.to
+}
@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"encoding/json"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSet_Options(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Location = config.LocationConfig{Latitude: 51.5074, Longitude: -0.1278}
+	cfg.Rules = []config.RuleConfig{
+		{Providers: []string{"unsplash"}, Hours: "06:00-11:00", Options: json.RawMessage(`{"query": "forest"}`)},
+		{Providers: []string{"unsplash"}, Sun: "night", Options: json.RawMessage(`{"query": "night city"}`)},
+		{Providers: []string{"wallhaven"}, Months: []string{"Dec", "january", "feb"}, Options: json.RawMessage(`{"color": "424153"}`)},
+		{Weekdays: []string{"sat", "Sunday"}, Hours: "22:00-02:00", Options: json.RawMessage(`{}`)},
+	}
+
+	s, err := New(cfg)
+	assert.NoError(t, err)
+
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2020, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// Monday morning in summer.
+	assert.Equal(t, []json.RawMessage{cfg.Rules[0].Options}, s.Options("unsplash", utc(time.June, 22, 7, 0)))
+	assert.Empty(t, s.Options("wallhaven", utc(time.June, 22, 7, 0)))
+
+	// Winter morning before sunrise matches both rules, the
+	// latter is applied last.
+	assert.Equal(t,
+		[]json.RawMessage{cfg.Rules[0].Options, cfg.Rules[1].Options},
+		s.Options("unsplash", utc(time.December, 21, 7, 0)),
+	)
+	assert.Equal(t, []json.RawMessage{cfg.Rules[2].Options}, s.Options("wallhaven", utc(time.January, 6, 12, 0)))
+
+	// Range spanning midnight on Sunday.
+	assert.Len(t, s.Options("local", utc(time.June, 21, 1, 59)), 1)
+	assert.Empty(t, s.Options("local", utc(time.June, 21, 2, 0)))
+	assert.Empty(t, s.Options("local", utc(time.June, 22, 1, 0)))
+
+	assert.Len(t, s.OptionsOf("unsplash"), 3)
+}
+
+func TestNew(t *testing.T) {
+	for _, rule := range []config.RuleConfig{
+		{Hours: "6-11"},
+		{Hours: "06:00-25:00"},
+		{Weekdays: []string{"someday"}},
+		{Months: []string{"ju"}},
+		{Sun: "noon"},
+		// Location is not configured.
+		{Sun: "day"},
+	} {
+		cfg := config.NewDefault()
+		cfg.Rules = []config.RuleConfig{rule}
+
+		_, err := New(cfg)
+		assert.Error(t, err, "%+v", rule)
+	}
+}
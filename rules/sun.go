@@ -0,0 +1,73 @@
+package rules
+
+import (
+	"math"
+	"time"
+)
+
+// sunriseZenith is zenith angle of the Sun at sunrise and sunset
+// in degrees, corrected for atmospheric refraction and size of
+// solar disk.
+const sunriseZenith = 90.833
+
+// SunTimes calculates sunrise and sunset of solar day of moment t
+// at specified location using NOAA solar equations. Returned times
+// are in UTC. If the Sun doesn't rise or set that day (polar day
+// or night), ok is false and daylight tells whether it's polar day.
+func SunTimes(t time.Time, latitude, longitude float64) (sunrise, sunset time.Time, daylight, ok bool) {
+	// Solar day is shifted from UTC day by longitude, so
+	// sunrise and sunset found are the closest to t.
+	solar := t.UTC().Add(time.Duration(longitude / 15 * float64(time.Hour)))
+	midnight := time.Date(solar.Year(), solar.Month(), solar.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Fractional year at noon in radians.
+	gamma := 2 * math.Pi / 365 * float64(midnight.YearDay()-1)
+
+	eqTime := 229.18 * (0.000075 +
+		0.001868*math.Cos(gamma) -
+		0.032077*math.Sin(gamma) -
+		0.014615*math.Cos(2*gamma) -
+		0.040849*math.Sin(2*gamma))
+
+	decl := 0.006918 -
+		0.399912*math.Cos(gamma) +
+		0.070257*math.Sin(gamma) -
+		0.006758*math.Cos(2*gamma) +
+		0.000907*math.Sin(2*gamma) -
+		0.002697*math.Cos(3*gamma) +
+		0.00148*math.Sin(3*gamma)
+
+	lat := latitude * math.Pi / 180
+
+	cosHourAngle := math.Cos(sunriseZenith*math.Pi/180)/(math.Cos(lat)*math.Cos(decl)) -
+		math.Tan(lat)*math.Tan(decl)
+
+	if cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false, false
+	}
+	if cosHourAngle < -1 {
+		return time.Time{}, time.Time{}, true, false
+	}
+
+	hourAngle := math.Acos(cosHourAngle) * 180 / math.Pi
+
+	// Minutes since UTC midnight.
+	sunriseMinutes := 720 - 4*(longitude+hourAngle) - eqTime
+	sunsetMinutes := 720 - 4*(longitude-hourAngle) - eqTime
+
+	sunrise = midnight.Add(time.Duration(sunriseMinutes * float64(time.Minute)))
+	sunset = midnight.Add(time.Duration(sunsetMinutes * float64(time.Minute)))
+
+	return sunrise, sunset, true, true
+}
+
+// IsDaylight reports whether moment t is between sunrise
+// and sunset at specified location.
+func IsDaylight(t time.Time, latitude, longitude float64) bool {
+	sunrise, sunset, daylight, ok := SunTimes(t, latitude, longitude)
+	if !ok {
+		return daylight
+	}
+
+	return !t.Before(sunrise) && t.Before(sunset)
+}
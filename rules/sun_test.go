@@ -0,0 +1,30 @@
+package rules
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSunTimes(t *testing.T) {
+	// London, summer solstice: sunrise 03:43 UTC, sunset 20:21 UTC.
+	noon := time.Date(2020, time.June, 21, 12, 0, 0, 0, time.UTC)
+	sunrise, sunset, _, ok := SunTimes(noon, 51.5074, -0.1278)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Date(2020, time.June, 21, 3, 43, 0, 0, time.UTC), sunrise, 3*time.Minute)
+	assert.WithinDuration(t, time.Date(2020, time.June, 21, 20, 21, 0, 0, time.UTC), sunset, 3*time.Minute)
+
+	// New York, winter: sunset 21:29 UTC, i.e. 16:29 local time.
+	evening := time.Date(2020, time.December, 1, 23, 0, 0, 0, time.UTC)
+	_, sunset, _, ok = SunTimes(evening, 40.7128, -74.0060)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Date(2020, time.December, 1, 21, 29, 0, 0, time.UTC), sunset, 3*time.Minute)
+	assert.False(t, IsDaylight(evening, 40.7128, -74.0060))
+
+	// Tromsø has polar day in June and polar night in December.
+	_, _, daylight, ok := SunTimes(noon, 69.6492, 18.9553)
+	assert.False(t, ok)
+	assert.True(t, daylight)
+	assert.True(t, IsDaylight(noon.Add(12*time.Hour), 69.6492, 18.9553))
+	assert.False(t, IsDaylight(time.Date(2020, time.December, 21, 12, 0, 0, 0, time.UTC), 69.6492, 18.9553))
+}
@@ -0,0 +1,234 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildarkarymoff/blider/provider"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/ildarkarymoff/blider/storage"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// prefetchRetryDelay is delay before next attempt to fill
+// queue after provider failed.
+const prefetchRetryDelay = 5 * time.Minute
+
+// prefetcher keeps queue of wallpapers downloaded and validated
+// in advance, so change doesn't wait for network. Queue is kept
+// in database and queue directory of local storage, so it
+// survives restarts.
+type prefetcher struct {
+	provider   provider.IProvider
+	repository *repository.Repository
+	storage    *storage.Storage
+	// depth is number of wallpapers kept in queue.
+	depth      int
+	retryDelay time.Duration
+	// mutex makes sure provider is asked for one
+	// wallpaper at a time.
+	mutex sync.Mutex
+	// wake is signalled when wallpaper is taken from queue.
+	wake chan struct{}
+}
+
+func newPrefetcher(
+	provider provider.IProvider,
+	repository *repository.Repository,
+	storage *storage.Storage,
+	depth int,
+) *prefetcher {
+	return &prefetcher{
+		provider:   provider,
+		repository: repository,
+		storage:    storage,
+		depth:      depth,
+		retryDelay: prefetchRetryDelay,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// run fills queue and refills it each time wallpaper is taken
+// until ctx is done. If provider fails, filling is retried after
+// retry delay. Returns at once if prefetching is disabled
+// (depth is zero).
+func (p *prefetcher) run(ctx context.Context) {
+	if p.depth <= 0 {
+		return
+	}
+
+	for {
+		err := p.fill(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.wake:
+			}
+			continue
+		}
+
+		log.Printf("[Prefetch] %v (%s error), retrying in %s", err, provider.KindOf(err), p.retryDelay)
+
+		timer := time.NewTimer(p.retryDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-p.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// fill prefetches wallpapers until queue is full.
+func (p *prefetcher) fill(ctx context.Context) error {
+	for {
+		length, err := p.repository.QueueLength()
+		if err != nil {
+			return err
+		}
+
+		if length >= p.depth {
+			return nil
+		}
+
+		if err := p.prefetch(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// prefetch obtains wallpaper and appends it to queue.
+func (p *prefetcher) prefetch(ctx context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	log.Println("Prefetching wallpaper...")
+
+	wallpaper, err := p.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Images picked from local folders are used in place.
+	if len(wallpaper.LocalPath) == 0 {
+		if err := p.storage.SaveToQueue(wallpaper.Filename, wallpaper.ImgBuffer); err != nil {
+			return err
+		}
+	}
+
+	if _, err := p.repository.Enqueue(wallpaper); err != nil {
+		if len(wallpaper.LocalPath) == 0 {
+			_ = p.storage.RemoveFromQueue(wallpaper.Filename)
+		}
+		return fmt.Errorf("[Enqueue] %v", err)
+	}
+
+	log.Printf("Prefetched '%s' by %s (%s)", wallpaper.Title, wallpaper.Author, wallpaper.OriginURL)
+	return nil
+}
+
+// fetch asks provider for wallpaper and makes sure it has valid image.
+func (p *prefetcher) fetch(ctx context.Context) (*repository.Wallpaper, error) {
+	wallpaper, err := p.provider.Provide(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	img := wallpaper.ImgBuffer
+	if len(wallpaper.LocalPath) > 0 {
+		img, err = ioutil.ReadFile(wallpaper.LocalPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := storage.ValidateImage(img); err != nil {
+		return nil, fmt.Errorf("[Validate image of %s] %v", wallpaper.OriginURL, err)
+	}
+
+	return wallpaper, nil
+}
+
+// next returns wallpaper to display: the first one from queue or,
+// if queue is empty, the one obtained from provider right away.
+// Image of returned wallpaper is already in local storage.
+func (p *prefetcher) next(ctx context.Context) (*repository.Wallpaper, error) {
+	if wallpaper := p.pop(); wallpaper != nil {
+		return wallpaper, nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Queue may have been filled while provider was busy.
+	if wallpaper := p.pop(); wallpaper != nil {
+		return wallpaper, nil
+	}
+
+	wallpaper, err := p.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wallpaper.LocalPath) == 0 {
+		log.Println("Saving image to local repository...")
+		if err := p.storage.Save(wallpaper.Filename, wallpaper.ImgBuffer); err != nil {
+			return nil, err
+		}
+	}
+
+	return wallpaper, nil
+}
+
+// pop takes the first wallpaper from queue and moves its image to
+// local storage. Wallpapers whose images have gone are skipped.
+// Returns nil if queue is empty or can't be read.
+func (p *prefetcher) pop() *repository.Wallpaper {
+	for {
+		wallpaper, err := p.repository.Dequeue()
+		if err != nil {
+			log.Printf("[Dequeue] %v", err)
+			return nil
+		}
+
+		if wallpaper == nil {
+			return nil
+		}
+
+		p.signal()
+
+		if len(wallpaper.LocalPath) > 0 {
+			_, err = os.Stat(wallpaper.LocalPath)
+		} else {
+			err = p.storage.MoveFromQueue(wallpaper.Filename)
+		}
+
+		if err != nil {
+			log.Printf("[Take prefetched '%s'] %v", wallpaper.Title, err)
+			continue
+		}
+
+		log.Println("Taking prefetched wallpaper...")
+		wallpaper.ID = 0
+
+		return wallpaper
+	}
+}
+
+// signal wakes prefetcher up to refill queue.
+func (p *prefetcher) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
@@ -1,6 +1,7 @@
 package schedule
 
 import (
+	"context"
 	"fmt"
 	"github.com/ildarkarymoff/blider/change/cmd"
 	"github.com/ildarkarymoff/blider/change/cmd/builder"
@@ -22,6 +23,7 @@ type Scheduler struct {
 	builder    *builder.ICmdBuilder
 	repository *repository.Repository
 	storage    *storage.Storage
+	prefetcher *prefetcher
 }
 
 func NewScheduler(
@@ -35,27 +37,54 @@ func NewScheduler(
 }
 
 // Start initializes Scheduler and starts provide-change loop.
+// Upcoming wallpapers are prefetched in background. Loop stops
+// when ctx is done, interrupting download in progress.
 // This method should be used only once.
-func (s *Scheduler) Start(config *config.Config) error {
+func (s *Scheduler) Start(ctx context.Context, config *config.Config) error {
 	s.config = config
 
 	if err := s.init(); err != nil {
 		return fmt.Errorf("[init] %v", err)
 	}
+	defer s.period.Stop()
 
 	(*s.provider).Init(s.config, s.repository)
 
-	if err := s.changeOp(); err != nil {
+	// Prefetcher is stopped and waited for whenever loop stops.
+	prefetched := make(chan struct{})
+	defer func() { <-prefetched }()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		s.prefetcher.run(ctx)
+		close(prefetched)
+	}()
+
+	if err := s.changeOp(ctx); err != nil {
+		if ctx.Err() != nil {
+			log.Println("Scheduler stopped")
+			return nil
+		}
 		return fmt.Errorf("[changeOp 1st time] %v", err)
 	}
 
-	for range s.period.C {
-		if err := s.changeOp(); err != nil {
-			return fmt.Errorf("[changeOp] %v", err)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Scheduler stopped")
+			return nil
+		case <-s.period.C:
+			if err := s.changeOp(ctx); err != nil {
+				if ctx.Err() != nil {
+					log.Println("Scheduler stopped")
+					return nil
+				}
+				return fmt.Errorf("[changeOp] %v", err)
+			}
 		}
 	}
-
-	return nil
 }
 
 func (s *Scheduler) init() error {
@@ -82,22 +111,42 @@ func (s *Scheduler) init() error {
 	}
 	s.storage = st
 
+	queue, err := s.repository.GetQueue()
+	if err != nil {
+		return err
+	}
+	log.Printf("%d prefetched wallpapers are queued", len(queue))
+
+	if err := s.storage.CleanUpQueue(queue); err != nil {
+		log.Printf("[storage.CleanUpQueue] %v", err)
+	}
+
+	s.prefetcher = newPrefetcher(*s.provider, s.repository, s.storage, s.config.PrefetchDepth)
+
 	log.Println("Initializing builder...")
 	(*s.builder).Init(s.config)
 
 	return nil
 }
 
-// changeOp asks provider to provider image then asks builder to
-// change wallpaper.
-func (s *Scheduler) changeOp() error {
+// changeOp takes prefetched wallpaper (or asks provider to provide
+// one if there is none) then asks builder to change wallpaper.
+func (s *Scheduler) changeOp(ctx context.Context) error {
 	log.Println("Change desktop wallpaper operation triggered")
-	wallpaper := (*s.provider).Provide()
+	wallpaper, err := s.prefetcher.next(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Printf("[Provide] %v (%s error)", err, provider.KindOf(err))
+		if provider.KindOf(err) == provider.Permanent {
+			log.Println("Providers can't work with current configuration, please check it")
+		}
 
-	// If image obtaining failed we don't want to wait another
-	// period, but should try to obtain again.
-	if len(wallpaper.ImgBuffer) == 0 {
-		return s.changeOp()
+		// If all providers failed, one of already downloaded
+		// images is displayed again as a last resort.
+		return s.redisplayOp()
 	}
 
 	log.Println("Saving image to database...")
@@ -108,13 +157,14 @@ func (s *Scheduler) changeOp() error {
 
 	wallpaper.ID = id
 
-	log.Println("Saving image to local repository...")
-	if err := s.storage.Save(wallpaper.Filename, wallpaper.ImgBuffer); err != nil {
-		return err
+	// Wallpaper that command can't be built for is skipped,
+	// so scheduler keeps running and tries the next one.
+	command := (*s.builder).Build(wallpaper)
+	if command == nil {
+		log.Printf("[Build command] Can't set '%s', skipping it", wallpaper.Title)
+		return nil
 	}
-	//s.saveImage(wallpaper.Filename, wallpaper.ImgBuffer)
 
-	command := (*s.builder).Build(wallpaper)
 	if err := cmd.Run(command); err != nil {
 		return err
 	}
@@ -135,3 +185,39 @@ func (s *Scheduler) changeOp() error {
 	log.Printf("Paused for %s", s.config.Period)
 	return nil
 }
+
+// redisplayOp asks builder to change wallpaper to random image
+// already present in local storage.
+func (s *Scheduler) redisplayOp() error {
+	log.Println("Failed to obtain new wallpaper. Picking one from local storage...")
+
+	wallpaper, err := s.storage.PickRandom()
+	if err != nil {
+		return fmt.Errorf("[storage.PickRandom] %v", err)
+	}
+
+	if wallpaper == nil {
+		log.Printf("Local storage is empty. Paused for %s", s.config.Period)
+		return nil
+	}
+
+	command := (*s.builder).Build(wallpaper)
+	if command == nil {
+		log.Printf("[Build command] Can't set '%s', skipping it", wallpaper.Title)
+		return nil
+	}
+
+	if err := cmd.Run(command); err != nil {
+		return err
+	}
+
+	log.Printf(
+		"Background changed back to '%s' by %s (%s)",
+		wallpaper.Title,
+		wallpaper.Author,
+		wallpaper.OriginURL,
+	)
+
+	log.Printf("Paused for %s", s.config.Period)
+	return nil
+}
@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"github.com/ildarkarymoff/blider/change/cmd/builder"
+	"github.com/ildarkarymoff/blider/config"
+	"github.com/ildarkarymoff/blider/repository"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubProvider gives the same local image on every call
+// and reports each call to calls channel.
+type stubProvider struct {
+	imgPath string
+	calls   chan struct{}
+}
+
+func (p *stubProvider) Init(*config.Config, *repository.Repository) {}
+
+func (p *stubProvider) Provide(context.Context) (*repository.Wallpaper, error) {
+	p.calls <- struct{}{}
+
+	return &repository.Wallpaper{
+		Filename:  filepath.Base(p.imgPath),
+		Title:     "Dunes",
+		LocalPath: p.imgPath,
+	}, nil
+}
+
+func TestScheduler_StartSurvivesUnbuiltCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blider_scheduler_test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var img bytes.Buffer
+	assert.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 2, 1))))
+	imgPath := filepath.Join(dir, "dunes.png")
+	assert.NoError(t, ioutil.WriteFile(imgPath, img.Bytes(), 0644))
+
+	cfg := config.NewDefault()
+	cfg.Period = "1s"
+	cfg.DBPath = filepath.Join(dir, "blider.sqlite")
+	cfg.LocalStoragePath = filepath.Join(dir, "images")
+
+	// Local wallpaper has no image buffer, so template
+	// can't be rendered for it.
+	templateBuilder, err := builder.NewTemplateCmdBuilder([]string{
+		"mytool",
+		"{{if .Title}}{{index .ImgBuffer 0}}{{end}}",
+	})
+	assert.NoError(t, err)
+	var cmdBuilder builder.ICmdBuilder = templateBuilder
+
+	p := &stubProvider{imgPath: imgPath, calls: make(chan struct{}, 10)}
+	s := NewScheduler(p, &cmdBuilder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Start(ctx, cfg)
+	}()
+
+	// Scheduler tries the next wallpaper after failed one.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-p.calls:
+		case err := <-done:
+			t.Fatalf("Scheduler stopped: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Scheduler didn't ask for wallpaper")
+		}
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ildarkarymoff/blider/repository"
+	"image"
+	// Decoders of formats validated by ValidateImage.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// queueDir is directory inside local storage where images of
+// prefetched wallpapers wait for their turn.
+const queueDir = ".queue"
+
+func (s *Storage) queuePath() string {
+	return filepath.Join(s.config.LocalStoragePath, queueDir)
+}
+
+// SaveToQueue writes image of prefetched wallpaper to queue directory.
+func (s *Storage) SaveToQueue(filename string, image []byte) error {
+	if err := os.MkdirAll(s.queuePath(), os.ModePerm); err != nil {
+		return err
+	}
+
+	wpPath := filepath.Join(s.queuePath(), filename)
+	if err := ioutil.WriteFile(wpPath, image, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to write image to %s: %v", wpPath, err)
+	}
+
+	log.Printf("Saved prefetched image to %s", wpPath)
+	return nil
+}
+
+// MoveFromQueue moves image of prefetched wallpaper from queue
+// directory to local storage.
+func (s *Storage) MoveFromQueue(filename string) error {
+	return os.Rename(
+		filepath.Join(s.queuePath(), filename),
+		filepath.Join(s.config.LocalStoragePath, filename),
+	)
+}
+
+// RemoveFromQueue removes image of prefetched wallpaper
+// from queue directory.
+func (s *Storage) RemoveFromQueue(filename string) error {
+	return os.Remove(filepath.Join(s.queuePath(), filename))
+}
+
+// CleanUpQueue removes images left in queue directory that
+// don't belong to any of queued wallpapers, e.g. if blider was
+// stopped between saving image and enqueueing wallpaper.
+func (s *Storage) CleanUpQueue(queue []*repository.Wallpaper) error {
+	files, err := ioutil.ReadDir(s.queuePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	queued := make(map[string]bool)
+	for _, w := range queue {
+		queued[w.Filename] = true
+	}
+
+	for _, file := range files {
+		if queued[file.Name()] || file.IsDir() {
+			continue
+		}
+
+		log.Printf("Removing orphaned prefetched image '%s'...", file.Name())
+		if err := s.RemoveFromQueue(file.Name()); err != nil {
+			return fmt.Errorf("[Remove '%s'] %v", file.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateImage makes sure data is image. Images of formats
+// Go can decode must have valid header, images of other formats
+// (e.g. WebP) are recognized by their signature only.
+func ValidateImage(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("image is empty")
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("data is %s, not image", contentType)
+	}
+
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		if imgConfig.Width <= 0 || imgConfig.Height <= 0 {
+			return errors.New("image has no pixels")
+		}
+	}
+
+	return nil
+}
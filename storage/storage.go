@@ -6,9 +6,11 @@ import (
 	"github.com/ildarkarymoff/blider/repository"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"os"
 	"path"
 	"path/filepath"
+	"time"
 )
 
 // Storage is object for managing local images storage.
@@ -62,11 +64,19 @@ func (s *Storage) Save(filename string, image []byte) error {
 // information from SQLite database.
 func (s *Storage) CleanUp() error {
 	log.Println("Checking local repository...")
-	files, err := ioutil.ReadDir(s.config.LocalStoragePath)
+	entries, err := ioutil.ReadDir(s.config.LocalStoragePath)
 	if err != nil {
 		return err
 	}
 
+	// Queue directory is not counted.
+	var files []os.FileInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, entry)
+		}
+	}
+
 	if len(files) <= s.config.LocalStorageLimit {
 		return nil
 	}
@@ -86,6 +96,12 @@ func (s *Storage) CleanUp() error {
 	}
 
 	for i := s.config.LocalStorageLimit + 1; i < len(wallpapers); i++ {
+		// Wallpapers picked from user's folders are not managed by
+		// local repository, so original files must stay untouched.
+		if len(wallpapers[i].LocalPath) > 0 {
+			continue
+		}
+
 		wpPath := filepath.Join(s.config.LocalStoragePath, wallpapers[i].Filename)
 
 		stat, err := os.Stat(wpPath)
@@ -102,3 +118,31 @@ func (s *Storage) CleanUp() error {
 
 	return nil
 }
+
+// PickRandom returns random wallpaper from history which image is
+// still present on disk. Returns nil if there is no such wallpaper.
+func (s *Storage) PickRandom() (*repository.Wallpaper, error) {
+	wallpapers, err := s.repository.GetWallpapers()
+	if err != nil {
+		return nil, err
+	}
+
+	var present []*repository.Wallpaper
+	for _, w := range wallpapers {
+		wpPath := w.LocalPath
+		if len(wpPath) == 0 {
+			wpPath = filepath.Join(s.config.LocalStoragePath, w.Filename)
+		}
+
+		if stat, err := os.Stat(wpPath); err == nil && !stat.IsDir() {
+			present = append(present, w)
+		}
+	}
+
+	if len(present) == 0 {
+		return nil, nil
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	return present[rand.Intn(len(present))], nil
+}
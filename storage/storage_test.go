@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/ildarkarymoff/blider/config"
 	"github.com/ildarkarymoff/blider/repository"
 	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -103,3 +107,90 @@ func TestStorage_CleanUp(t *testing.T) {
 
 	assert.Error(t, storage.CleanUp())
 }
+
+func TestStorage_CleanUpKeepsLocalFiles(t *testing.T) {
+	assert.NoError(t, rep.ClearHistory())
+	defer rep.ClearHistory()
+
+	userDir, err := ioutil.TempDir("", "blider_user_images")
+	assert.NoError(t, err)
+	defer os.RemoveAll(userDir)
+
+	localCfg := *cfg
+	localCfg.LocalStoragePath, err = ioutil.TempDir("", "blider_storage")
+	localCfg.LocalStorageLimit = 1
+	assert.NoError(t, err)
+	defer os.RemoveAll(localCfg.LocalStoragePath)
+
+	storage, err := Open(&localCfg, rep)
+	assert.NoError(t, err)
+
+	// Local wallpaper is the oldest one, so it would be removed first
+	// if it were managed by local repository.
+	original := filepath.Join(userDir, "original.png")
+	assert.NoError(t, ioutil.WriteFile(original, []byte{}, os.ModePerm))
+	assert.NoError(t, storage.Save("original.png", []byte{}))
+
+	_, err = rep.AddWallpaper(&repository.Wallpaper{
+		Filename:       "original.png",
+		FetchTimestamp: 1,
+		LocalPath:      original,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		filename := fmt.Sprintf("downloaded_%d.png", i)
+		assert.NoError(t, storage.Save(filename, []byte{}))
+
+		_, err = rep.AddWallpaper(&repository.Wallpaper{
+			Filename:       filename,
+			FetchTimestamp: uint(10 + i),
+		})
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, storage.CleanUp())
+
+	assert.FileExists(t, original)
+	assert.FileExists(t, filepath.Join(localCfg.LocalStoragePath, "original.png"))
+	assert.FileExists(t, filepath.Join(localCfg.LocalStoragePath, "downloaded_2.png"))
+	assert.FileExists(t, filepath.Join(localCfg.LocalStoragePath, "downloaded_1.png"))
+	_, err = os.Stat(filepath.Join(localCfg.LocalStoragePath, "downloaded_0.png"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStorage_Queue(t *testing.T) {
+	localCfg := *cfg
+	var err error
+	localCfg.LocalStoragePath, err = ioutil.TempDir("", "blider_storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(localCfg.LocalStoragePath)
+
+	storage, err := Open(&localCfg, rep)
+	assert.NoError(t, err)
+
+	assert.NoError(t, storage.SaveToQueue("queued.png", []byte{1}))
+	assert.NoError(t, storage.SaveToQueue("orphaned.png", []byte{2}))
+
+	assert.NoError(t, storage.CleanUpQueue([]*repository.Wallpaper{{Filename: "queued.png"}}))
+	assert.FileExists(t, filepath.Join(localCfg.LocalStoragePath, queueDir, "queued.png"))
+	_, err = os.Stat(filepath.Join(localCfg.LocalStoragePath, queueDir, "orphaned.png"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, storage.MoveFromQueue("queued.png"))
+	assert.FileExists(t, filepath.Join(localCfg.LocalStoragePath, "queued.png"))
+	assert.Error(t, storage.MoveFromQueue("queued.png"))
+}
+
+func TestValidateImage(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 2, 1))))
+	img := buf.Bytes()
+
+	assert.NoError(t, ValidateImage(img))
+	assert.NoError(t, ValidateImage([]byte("RIFF\x00\x00\x00\x00WEBPVP8 ")))
+
+	assert.Error(t, ValidateImage(nil))
+	assert.Error(t, ValidateImage([]byte("<html><body>Not found</body></html>")))
+	assert.Error(t, ValidateImage(img[:12]))
+}